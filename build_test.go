@@ -0,0 +1,58 @@
+package forg_test
+
+import (
+	"context"
+	"github.com/aacfactory/forg"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoad_BuildConstraints(t *testing.T) {
+	dir := copyProject(t, filepath.Join("testdata", "standalone"))
+	writes := map[string]string{
+		"modules/users/find.go":         "package users\n\nimport (\n\t\"context\"\n\t\"github.com/aacfactory/errors\"\n)\n\n// find\n// @fn find\n// @title Find user\nfunc find(ctx context.Context, param FindParam) (result User, err errors.CodeError) {\n\treturn\n}\n",
+		"modules/users/find_linux.go":   "package users\n\n// FindParam\n// @title find param\ntype FindParam struct {\n\tLinux string `json:\"linux\"`\n\tPlan  Plan   `json:\"plan\"`\n}\n",
+		"modules/users/find_windows.go": "package users\n\n// FindParam\n// @title find param\ntype FindParam struct {\n\tWindows string `json:\"windows\"`\n\tPlan    Plan   `json:\"plan\"`\n}\n",
+		"modules/users/plan_pro.go":     "//go:build pro\n\npackage users\n\ntype Plan struct {\n\tPro bool `json:\"pro\"`\n}\n",
+		"modules/users/plan_free.go":    "//go:build !pro\n\npackage users\n\ntype Plan struct {\n\tFree bool `json:\"free\"`\n}\n",
+	}
+	for name, content := range writes {
+		if err := os.WriteFile(filepath.Join(dir, filepath.FromSlash(name)), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cases := []struct {
+		options []forg.Option
+		field   string
+		plan    string
+	}{
+		{options: []forg.Option{forg.WithGOOS("linux")}, field: "Linux", plan: "Free"},
+		{options: []forg.Option{forg.WithGOOS("windows"), forg.WithTags("pro")}, field: "Windows", plan: "Pro"},
+	}
+	for _, c := range cases {
+		p, loadErr := forg.Load(dir, c.options...)
+		if loadErr != nil {
+			t.Fatalf("%+v", loadErr)
+		}
+		model, inspectErr := p.Inspect(context.TODO())
+		if inspectErr != nil {
+			t.Fatalf("%+v", inspectErr)
+		}
+		var param *forg.TypeModel
+		for _, fn := range model.Services[0].Functions {
+			if fn.Name == "find" && fn.Param != nil {
+				param = fn.Param.Type
+			}
+		}
+		if param == nil || len(param.Elements) != 2 {
+			t.Fatalf("unexpected param: %+v", param)
+		}
+		if name := param.Elements[0].Name; name != c.field {
+			t.Errorf("expect field %s, got %s", c.field, name)
+		}
+		if plan := param.Elements[1].Elements[0]; len(plan.Elements) != 1 || plan.Elements[0].Name != c.plan {
+			t.Errorf("expect plan field %s, got %+v", c.plan, plan.Elements)
+		}
+	}
+}
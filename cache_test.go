@@ -0,0 +1,191 @@
+package forg_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/aacfactory/forg"
+	"github.com/aacfactory/forg/module"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoad_Cache(t *testing.T) {
+	dir := copyProject(t, filepath.Join("testdata", "standalone"))
+	cache := t.TempDir()
+	// a dir of old version is removed, and other files are kept
+	old := filepath.Join(cache, "old")
+	if err := os.MkdirAll(old, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(old, "version"), []byte("0 old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	other := filepath.Join(cache, "other")
+	if err := os.MkdirAll(other, 0755); err != nil {
+		t.Fatal(err)
+	}
+	inspect := func() (p []byte) {
+		project, loadErr := forg.Load(dir, forg.WithCache(cache))
+		if loadErr != nil {
+			t.Fatalf("%+v", loadErr)
+		}
+		model, inspectErr := project.Inspect(context.TODO())
+		if inspectErr != nil {
+			t.Fatalf("%+v", inspectErr)
+		}
+		p, _ = json.Marshal(model.Services)
+		return
+	}
+	first := inspect()
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Errorf("expect cache of old version is removed")
+	}
+	if _, err := os.Stat(other); err != nil {
+		t.Errorf("expect other dir is kept: %v", err)
+	}
+	entries := 0
+	_ = filepath.Walk(cache, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() && strings.HasSuffix(path, ".json") {
+			entries++
+		}
+		return nil
+	})
+	if entries == 0 {
+		t.Fatal("expect entries in cache")
+	}
+	if second := inspect(); string(second) != string(first) {
+		t.Fatalf("expect same model from cache\n%s\n%s", first, second)
+	}
+	// changed type invalidates the entry of function
+	getFilename := filepath.Join(dir, "modules", "users", "get.go")
+	p, readErr := os.ReadFile(getFilename)
+	if readErr != nil {
+		t.Fatal(readErr)
+	}
+	p = []byte(strings.Replace(string(p), "Tags []string `json:\"tags\"`", "Tags []string `json:\"tags\"`\n\tAge  int      `json:\"age\"`", 1))
+	if err := os.WriteFile(getFilename, p, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if third := inspect(); !strings.Contains(string(third), "\"age\"") {
+		t.Fatalf("expect changed type, got %s", third)
+	}
+	// changed config invalidates entries of services, because imports of services are made by it
+	config := "imports:\n  service: example.com/fns/service\n"
+	if err := os.WriteFile(filepath.Join(dir, "forg.yaml"), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	inspect()
+	project, loadErr := forg.Load(dir, forg.WithCache(cache))
+	if loadErr != nil {
+		t.Fatalf("%+v", loadErr)
+	}
+	if err := project.Mod.Parse(context.TODO()); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	services, servicesErr := project.Mod.Services()
+	if servicesErr != nil {
+		t.Fatalf("%+v", servicesErr)
+	}
+	if _, has := services[0].Imports.Path("example.com/fns/service"); !has {
+		t.Fatalf("expect imports of changed config, got %+v", services[0].Imports)
+	}
+	if err := module.CleanCache(cache); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	entries = 0
+	_ = filepath.Walk(cache, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() && strings.HasSuffix(path, ".json") {
+			entries++
+		}
+		return nil
+	})
+	if entries != 0 {
+		t.Errorf("expect no entries after clean, got %d", entries)
+	}
+}
+
+func TestLoad_CacheOfRequires(t *testing.T) {
+	dir := copyProject(t, filepath.Join("testdata", "standalone"))
+	modCache := t.TempDir()
+	cache := t.TempDir()
+	writes := map[string]string{
+		"modules/users/find.go": "package users\n\nimport (\n\t\"context\"\n\t\"example.com/lib\"\n\t\"github.com/aacfactory/errors\"\n)\n\n// find\n// @fn find\n// @title Find user\nfunc find(ctx context.Context, param lib.Query) (result User, err errors.CodeError) {\n\treturn\n}\n",
+		"lib/go.mod":            "module example.com/lib\n\ngo 1.20\n",
+		"lib/lib.go":            "package lib\n\n// Query\n// @title query\ntype Query struct {\n\tSize int `json:\"size\"`\n}\n",
+		// download cache
+		"@cache/cache/download/example.com/lib/@v/v1.0.0.mod": "module example.com/lib\n\ngo 1.20\n",
+		"@cache/cache/download/example.com/lib/@v/v1.1.0.mod": "module example.com/lib\n\ngo 1.20\n",
+		// extracted modules
+		"@cache/example.com/lib@v1.0.0/lib.go": "package lib\n\n// Query\n// @title query\ntype Query struct {\n\tOffset int `json:\"offset\"`\n}\n",
+		"@cache/example.com/lib@v1.1.0/lib.go": "package lib\n\n// Query\n// @title query\ntype Query struct {\n\tPage int `json:\"page\"`\n}\n",
+	}
+	for name, content := range writes {
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		if rest, isCache := strings.CutPrefix(name, "@cache/"); isCache {
+			filename = filepath.Join(modCache, filepath.FromSlash(rest))
+		}
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("GOENV", "off")
+	t.Setenv("GOMODCACHE", modCache)
+	t.Setenv("GOFLAGS", "")
+	// entries of cache are not used when the version or the replacement of a require is changed
+	cases := []struct {
+		name  string
+		mod   string
+		field string
+	}{
+		{name: "v1.0.0", mod: "require example.com/lib v1.0.0\n", field: "Offset"},
+		{name: "v1.1.0", mod: "require example.com/lib v1.1.0\n", field: "Page"},
+		{name: "replace", mod: "require example.com/lib v1.1.0\n\nreplace example.com/lib => ./lib\n", field: "Size"},
+		{name: "cached v1.0.0", mod: "require example.com/lib v1.0.0\n", field: "Offset"},
+	}
+	entries := func() (n int) {
+		_ = filepath.Walk(cache, func(path string, info os.FileInfo, err error) error {
+			if err == nil && !info.IsDir() && strings.HasSuffix(path, ".json") {
+				n++
+			}
+			return nil
+		})
+		return
+	}
+	for i, c := range cases {
+		// go.sum is changed with go.mod, so entries are keyed by it even when sources of packages are same
+		if err := os.WriteFile(filepath.Join(dir, "go.sum"), []byte(fmt.Sprintf("example.com/lib v1.%d.0/go.mod h1:%d=\n", i, i)), 0644); err != nil {
+			t.Fatal(err)
+		}
+		before := entries()
+		mod := "module example.com/standalone\n\ngo 1.20\n\nrequire github.com/aacfactory/errors v1.13.4\n\n" + c.mod
+		if err := os.WriteFile(filepath.Join(dir, "go.mod"), []byte(mod), 0644); err != nil {
+			t.Fatal(err)
+		}
+		project, loadErr := forg.Load(dir, forg.WithCache(cache))
+		if loadErr != nil {
+			t.Fatalf("%s: %+v", c.name, loadErr)
+		}
+		model, inspectErr := project.Inspect(context.TODO())
+		if inspectErr != nil {
+			t.Fatalf("%s: %+v", c.name, inspectErr)
+		}
+		field := ""
+		for _, fn := range model.Services[0].Functions {
+			if fn.Name == "find" && fn.Param != nil && len(fn.Param.Type.Elements) == 1 {
+				field = fn.Param.Type.Elements[0].Name
+			}
+		}
+		if field != c.field {
+			t.Fatalf("%s: expect field %s of param, got %q", c.name, c.field, field)
+		}
+		if after := entries(); after <= before {
+			t.Fatalf("%s: expect new entries of changed dependencies, got %d before and %d after", c.name, before, after)
+		}
+	}
+}
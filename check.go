@@ -0,0 +1,107 @@
+package forg
+
+import (
+	"context"
+	"github.com/aacfactory/errors"
+	"github.com/aacfactory/forg/codes"
+	"github.com/aacfactory/forg/configs"
+	"github.com/aacfactory/forg/module"
+)
+
+const (
+	outOfDateMessage = "forg: generated files are out of date"
+)
+
+func IsOutOfDateErr(err error) (ok bool) {
+	if err == nil {
+		return
+	}
+	ok = errors.Map(err).Contains(messageError(outOfDateMessage))
+	return
+}
+
+// messageError is the target of Contains, Contains of code error matches messages by Error of target.
+type messageError string
+
+func (e messageError) Error() string {
+	return string(e)
+}
+
+func (project *Project) Check(ctx context.Context) (stales []*codes.FileDiff, err error) {
+	parseErr := project.Mod.Parse(ctx)
+	if parseErr != nil {
+		err = errors.Warning("forg: project check failed").WithCause(parseErr)
+		return
+	}
+	services, servicesErr := project.Mod.Services()
+	if servicesErr != nil {
+		err = errors.Warning("forg: project check failed").WithCause(servicesErr)
+		return
+	}
+	for _, service := range services {
+		if !project.parsingRequired() {
+			break
+		}
+		for _, function := range service.Functions {
+			parseFnErr := function.Parse(ctx)
+			if parseFnErr != nil {
+				err = errors.Warning("forg: project check failed").WithCause(parseFnErr)
+				return
+			}
+		}
+	}
+	stales = make([]*codes.FileDiff, 0, 1)
+	files, filesErr := project.codeFiles(ctx, services)
+	if filesErr != nil {
+		err = errors.Warning("forg: project check failed").WithCause(filesErr)
+		return
+	}
+	for _, file := range files {
+		diff, diffErr := codes.DiffFS(ctx, file, project.Mod.Dir, project.fsys)
+		if diffErr != nil {
+			err = errors.Warning("forg: project check failed").WithCause(diffErr)
+			return
+		}
+		if diff.Changed {
+			stales = append(stales, diff)
+		}
+	}
+	if len(stales) == 0 {
+		return
+	}
+	staleErrs := errors.MakeErrors()
+	for _, stale := range stales {
+		if stale.Exist {
+			staleErrs.Append(errors.Warning("forg: generated file is stale").WithMeta("file", stale.Filename).WithMeta("status", "stale"))
+		} else {
+			staleErrs.Append(errors.Warning("forg: generated file is missing").WithMeta("file", stale.Filename).WithMeta("status", "missing"))
+		}
+	}
+	err = errors.Warning(outOfDateMessage).WithCause(staleErrs.Error())
+	return
+}
+
+// codeFiles returns files of enabled generators, units of registered generators are not included.
+func (project *Project) codeFiles(ctx context.Context, services module.Services) (files []codes.CodeFile, err error) {
+	files = make([]codes.CodeFile, 0, len(services)+1)
+	if project.Config.Enabled(configs.ServicesGenerator) {
+		for _, service := range services {
+			files = append(files, codes.NewServiceFile(service, project.Config))
+		}
+	}
+	if project.Config.Enabled(configs.DeploysGenerator) {
+		files = append(files, project.deploysFile(services))
+	}
+	for _, generator := range project.generators {
+		if !project.Config.Enabled(generator.Name()) {
+			continue
+		}
+		generated, _, generateErr := project.generate(ctx, generator, services)
+		if generateErr != nil {
+			err = generateErr
+			return
+		}
+		files = append(files, generated...)
+	}
+	return
+}
@@ -0,0 +1,70 @@
+package forg_test
+
+import (
+	"context"
+	"github.com/aacfactory/forg"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func copyProject(t *testing.T, src string) (dst string) {
+	dst = t.TempDir()
+	err := filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, relErr := filepath.Rel(src, path)
+		if relErr != nil {
+			return relErr
+		}
+		target := filepath.Join(dst, rel)
+		if info.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		p, readErr := os.ReadFile(path)
+		if readErr != nil {
+			return readErr
+		}
+		return os.WriteFile(target, p, 0644)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return
+}
+
+func TestProject_Check(t *testing.T) {
+	dir := copyProject(t, filepath.Join("testdata", "standalone"))
+	p, loadErr := forg.Load(dir)
+	if loadErr != nil {
+		t.Fatalf("%+v", loadErr)
+	}
+	stales, checkErr := p.Check(context.TODO())
+	if !forg.IsOutOfDateErr(checkErr) {
+		t.Fatalf("expected out of date error, got %+v", checkErr)
+	}
+	if len(stales) != 2 {
+		t.Fatalf("expected 2 stale files, got %d", len(stales))
+	}
+	process, codingErr := p.Coding(context.TODO())
+	if codingErr != nil {
+		t.Fatalf("%+v", codingErr)
+	}
+	for result := range process.Start(context.TODO()) {
+		if result.Error != nil {
+			t.Fatalf("%+v", result.Error)
+		}
+	}
+	p, loadErr = forg.Load(dir)
+	if loadErr != nil {
+		t.Fatalf("%+v", loadErr)
+	}
+	stales, checkErr = p.Check(context.TODO())
+	if checkErr != nil {
+		t.Fatalf("%+v", checkErr)
+	}
+	if len(stales) != 0 {
+		t.Fatalf("expected no stale files, got %d", len(stales))
+	}
+}
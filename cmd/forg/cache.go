@@ -0,0 +1,54 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/aacfactory/forg/module"
+	"os"
+)
+
+func runCache(args []string) (code int) {
+	pf := &projectFlags{}
+	flags := flag.NewFlagSet("cache", flag.ContinueOnError)
+	flags.StringVar(&pf.cache, "cache", "", "parse cache dir, default is FORGCACHE or forg in user cache dir")
+	flags.BoolVar(&pf.verbose, "v", false, "print error details")
+	flags.Usage = func() {
+		_, _ = fmt.Fprintln(flags.Output(), "usage: forg cache [flags] clean")
+		flags.PrintDefaults()
+	}
+	flags.SetOutput(os.Stderr)
+	if parseErr := flags.Parse(args); parseErr != nil {
+		if parseErr == flag.ErrHelp {
+			code = exitOK
+			return
+		}
+		code = exitUsage
+		return
+	}
+	if flags.NArg() != 1 || flags.Arg(0) != "clean" {
+		flags.Usage()
+		code = exitUsage
+		return
+	}
+	dir := pf.cache
+	if dir == "" {
+		var dirErr error
+		dir, dirErr = module.CacheDir()
+		if dirErr != nil {
+			printError(pf, dirErr)
+			code = exitFailed
+			return
+		}
+	}
+	if dir == "" || dir == module.CacheOff {
+		_, _ = fmt.Fprintln(os.Stdout, "forg: cache is off")
+		return
+	}
+	if cleanErr := module.CleanCache(dir); cleanErr != nil {
+		printError(pf, cleanErr)
+		code = exitFailed
+		return
+	}
+	_, _ = fmt.Fprintf(os.Stdout, "removed %s\n", dir)
+	return
+}
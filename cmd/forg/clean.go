@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+)
+
+func runClean(args []string) (code int) {
+	pf := &projectFlags{}
+	flags := flag.NewFlagSet("clean", flag.ContinueOnError)
+	pf.register(flags)
+	flags.Usage = func() {
+		_, _ = fmt.Fprintln(flags.Output(), "usage: forg clean [flags] [project dir]")
+		flags.PrintDefaults()
+	}
+	dir, parseCode, ok := parseFlags(flags, args)
+	if !ok {
+		code = parseCode
+		return
+	}
+	if err := pf.validate(); err != nil {
+		printError(pf, err)
+		code = exitUsage
+		return
+	}
+	project, loadErr := pf.load(dir, pf.options()...)
+	if loadErr != nil {
+		printError(pf, loadErr)
+		code = exitFailed
+		return
+	}
+	removed, cleanErr := project.Clean(context.Background())
+	if cleanErr != nil {
+		printError(pf, cleanErr)
+		code = exitFailed
+		return
+	}
+	if pf.output == jsonOutput {
+		p, _ := json.Marshal(removed)
+		_, _ = fmt.Fprintf(os.Stdout, "%s\n", p)
+		return
+	}
+	for _, filename := range removed {
+		_, _ = fmt.Fprintf(os.Stdout, "removed %s\n", filename)
+	}
+	if pf.verbose || len(removed) == 0 {
+		_, _ = fmt.Fprintf(os.Stdout, "forg: %d files removed\n", len(removed))
+	}
+	return
+}
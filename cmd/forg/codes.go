@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/aacfactory/forg"
+	"github.com/aacfactory/forg/processes"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+func runCodes(args []string) (code int) {
+	pf := &projectFlags{}
+	flags := flag.NewFlagSet("codes", flag.ContinueOnError)
+	pf.register(flags)
+	check := flags.Bool("check", false, "fail if generated files are stale or missing, without writing them")
+	dryRun := flags.Bool("dry-run", false, "render files in memory and print unified diffs instead of writing them")
+	abortTimeout := flags.Duration("abort-timeout", 10*time.Second, "max time to wait for running units after an interrupt")
+	watch := flags.Bool("watch", false, "keep running and regenerate code files of services when their sources change")
+	interval := flags.Duration("interval", 500*time.Millisecond, "poll interval of watch mode")
+	flags.Usage = func() {
+		_, _ = fmt.Fprintln(flags.Output(), "usage: forg codes [flags] [project dir]")
+		flags.PrintDefaults()
+	}
+	dir, parseCode, ok := parseFlags(flags, args)
+	if !ok {
+		code = parseCode
+		return
+	}
+	if err := pf.validate(); err != nil {
+		printError(pf, err)
+		code = exitUsage
+		return
+	}
+	// events show running units in live mode and timings in verbose and json output
+	options := append(pf.options(), forg.WithEvents())
+	if *dryRun {
+		options = append(options, forg.WithDryRun())
+	}
+	project, loadErr := pf.load(dir, options...)
+	if loadErr != nil {
+		printError(pf, loadErr)
+		code = exitFailed
+		return
+	}
+	ctx := context.Background()
+	if *check {
+		code = runCheck(ctx, pf, project)
+		return
+	}
+	if *watch {
+		code = runWatch(ctx, pf, project, *interval)
+		return
+	}
+	process, codingErr := project.Coding(ctx)
+	if codingErr != nil {
+		printError(pf, codingErr)
+		code = exitFailed
+		return
+	}
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	results := process.Start(ctx)
+	d := newDisplay(os.Stdout, pf.output, pf.verbose)
+	aborting := false
+	for {
+		select {
+		case <-signals:
+			if aborting {
+				code = exitAborted
+				return
+			}
+			aborting = true
+			go func() {
+				abortErr := process.Abort(*abortTimeout)
+				if abortErr != nil {
+					printError(pf, abortErr)
+				}
+			}()
+			break
+		case result, has := <-results:
+			if !has {
+				code = d.Done()
+				if aborting && code == exitOK {
+					code = exitAborted
+				}
+				return
+			}
+			d.Show(result)
+			break
+		}
+	}
+}
+
+func runWatch(ctx context.Context, pf *projectFlags, project *forg.Project, interval time.Duration) (code int) {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if pf.output == textOutput {
+		_, _ = fmt.Fprintf(os.Stdout, "forg: watching %s, press ctrl+c to stop\n", filepath.ToSlash(filepath.Join(project.Mod.Dir, project.Config.Modules)))
+	}
+	watchErr := project.Watch(ctx, interval, func(changed []string, results <-chan processes.Result) {
+		if pf.output == textOutput && len(changed) > 0 {
+			_, _ = fmt.Fprintf(os.Stdout, "forg: regenerating %s\n", strings.Join(changed, ", "))
+		}
+		d := newDisplay(os.Stdout, pf.output, pf.verbose)
+		for result := range results {
+			d.Show(result)
+		}
+		d.Done()
+	})
+	if watchErr != nil {
+		printError(pf, watchErr)
+		code = exitFailed
+		return
+	}
+	return
+}
+
+type staleMessage struct {
+	File   string `json:"file"`
+	Status string `json:"status"`
+}
+
+func runCheck(ctx context.Context, pf *projectFlags, project *forg.Project) (code int) {
+	stales, checkErr := project.Check(ctx)
+	if checkErr != nil && !forg.IsOutOfDateErr(checkErr) {
+		printError(pf, checkErr)
+		code = exitFailed
+		return
+	}
+	messages := make([]staleMessage, 0, len(stales))
+	for _, stale := range stales {
+		message := staleMessage{
+			File:   stale.Filename,
+			Status: "stale",
+		}
+		if rel, relErr := filepath.Rel(project.Mod.Dir, stale.Filename); relErr == nil {
+			message.File = filepath.ToSlash(rel)
+		}
+		if !stale.Exist {
+			message.Status = "missing"
+		}
+		messages = append(messages, message)
+	}
+	if pf.output == jsonOutput {
+		p, _ := json.Marshal(messages)
+		_, _ = fmt.Fprintf(os.Stdout, "%s\n", p)
+	} else {
+		for i, message := range messages {
+			_, _ = fmt.Fprintf(os.Stdout, "%-7s %s\n", message.Status, message.File)
+			if pf.verbose {
+				_, _ = fmt.Fprint(os.Stdout, stales[i].Unified)
+			}
+		}
+		if len(messages) == 0 {
+			_, _ = fmt.Fprintln(os.Stdout, "forg: generated files are up to date")
+		} else {
+			_, _ = fmt.Fprintf(os.Stdout, "forg: %d generated files are out of date, run 'forg codes' to regenerate them\n", len(messages))
+		}
+	}
+	if len(messages) > 0 {
+		code = exitFailed
+	}
+	return
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/aacfactory/errors"
+	"github.com/aacfactory/forg/codes"
+	"github.com/aacfactory/forg/module"
+	"github.com/aacfactory/forg/processes"
+	"io"
+	"os"
+	"time"
+)
+
+type display struct {
+	out      io.Writer
+	output   string
+	verbose  bool
+	live     bool
+	dirty    bool
+	failed   int64
+	aborted  bool
+	finished int64
+}
+
+func newDisplay(out *os.File, output string, verbose bool) *display {
+	live := false
+	if output == textOutput && !verbose {
+		stat, statErr := out.Stat()
+		live = statErr == nil && stat.Mode()&os.ModeCharDevice != 0
+	}
+	return &display{
+		out:      out,
+		output:   output,
+		verbose:  verbose,
+		live:     live,
+		dirty:    false,
+		failed:   0,
+		aborted:  false,
+		finished: 0,
+	}
+}
+
+type resultMessage struct {
+	StepNo   int64       `json:"stepNo"`
+	StepNum  int64       `json:"stepNum"`
+	StepName string      `json:"stepName"`
+	UnitNo   int64       `json:"unitNo"`
+	UnitNum  int64       `json:"unitNum"`
+	Attempt  int64       `json:"attempt,omitempty"`
+	Event    string      `json:"event"`
+	Time     time.Time   `json:"time"`
+	Duration int64       `json:"durationMs,omitempty"`
+	Status   string      `json:"status"`
+	Data     interface{} `json:"data,omitempty"`
+	Error    interface{} `json:"error,omitempty"`
+}
+
+func resultStatus(result processes.Result) (status string) {
+	if result.Retrying {
+		status = "retrying"
+		return
+	}
+	if result.Event == processes.UnitStarted || result.Event == processes.StepStarted {
+		status = "started"
+		return
+	}
+	status = "succeed"
+	if result.Error != nil {
+		if processes.IsAbortErr(result.Error) {
+			status = "aborted"
+		} else {
+			status = "failed"
+		}
+	}
+	return
+}
+
+func (d *display) Show(result processes.Result) {
+	status := resultStatus(result)
+	// only last results of units are counted, events of steps carry errors of their units
+	unit := result.Event == processes.UnitFinished && !result.Retrying
+	if unit {
+		d.finished++
+		switch status {
+		case "failed":
+			d.failed++
+			break
+		case "aborted":
+			d.aborted = true
+			break
+		}
+	}
+	if d.output == jsonOutput {
+		message := resultMessage{
+			StepNo:   result.StepNo,
+			StepNum:  result.StepNum,
+			StepName: result.StepName,
+			UnitNo:   result.UnitNo,
+			UnitNum:  result.UnitNum,
+			Attempt:  result.Attempt,
+			Event:    result.Event.String(),
+			Time:     result.Time,
+			Duration: result.Duration.Milliseconds(),
+			Status:   status,
+			Data:     result.Data,
+			Error:    nil,
+		}
+		if result.Error != nil {
+			message.Error = errors.Map(result.Error)
+		}
+		p, encodeErr := json.Marshal(message)
+		if encodeErr != nil {
+			message.Data = fmt.Sprintf("%v", result.Data)
+			p, _ = json.Marshal(message)
+		}
+		_, _ = fmt.Fprintf(d.out, "%s\n", p)
+		return
+	}
+	if result.Event != processes.UnitFinished {
+		d.showEvent(result)
+		return
+	}
+	if diff, isDiff := result.Data.(*codes.FileDiff); isDiff && diff.Changed {
+		if d.dirty {
+			_, _ = fmt.Fprint(d.out, "\r\033[K")
+			d.dirty = false
+		}
+		_, _ = fmt.Fprint(d.out, diff.Unified)
+	}
+	if d.live {
+		_, _ = fmt.Fprintf(d.out, "\r\033[K%s", result.String())
+		d.dirty = true
+		if result.Error != nil {
+			_, _ = fmt.Fprintf(d.out, "\n%s\n", module.ErrorMessages(result.Error, true))
+			d.dirty = false
+		}
+		return
+	}
+	if d.verbose {
+		if diff, isDiff := result.Data.(*codes.FileDiff); isDiff {
+			if !diff.Changed {
+				_, _ = fmt.Fprintf(d.out, "%s %s\n", result.String(), diff.String())
+			} else {
+				_, _ = fmt.Fprintf(d.out, "%s %s: changed\n", result.String(), diff.Filename)
+			}
+		} else if result.Data != nil {
+			_, _ = fmt.Fprintf(d.out, "%s %v\n", result.String(), result.Data)
+		} else {
+			_, _ = fmt.Fprintf(d.out, "%s\n", result.String())
+		}
+		if result.Error != nil {
+			_, _ = fmt.Fprintf(d.out, "%+v\n", result.Error)
+		}
+		return
+	}
+	if result.Error != nil {
+		_, _ = fmt.Fprintf(d.out, "%s\n%s\n", result.String(), module.ErrorMessages(result.Error, true))
+	}
+}
+
+// showEvent shows running units in live mode, and timings of steps and the process in verbose mode.
+func (d *display) showEvent(result processes.Result) {
+	if d.live {
+		if result.Event == processes.UnitStarted {
+			_, _ = fmt.Fprintf(d.out, "\r\033[K%s", result.String())
+			d.dirty = true
+		}
+		return
+	}
+	if d.verbose && result.Event != processes.UnitStarted {
+		_, _ = fmt.Fprintf(d.out, "%s\n", result.String())
+	}
+}
+
+func (d *display) Done() (code int) {
+	if d.dirty {
+		_, _ = fmt.Fprintln(d.out)
+		d.dirty = false
+	}
+	if d.aborted {
+		code = exitAborted
+	} else if d.failed > 0 {
+		code = exitFailed
+	}
+	if d.output == textOutput {
+		switch code {
+		case exitAborted:
+			_, _ = fmt.Fprintln(d.out, "forg: aborted")
+			break
+		case exitFailed:
+			_, _ = fmt.Fprintf(d.out, "forg: %d of %d units failed\n", d.failed, d.finished)
+			break
+		default:
+			_, _ = fmt.Fprintf(d.out, "forg: %d units succeed\n", d.finished)
+			break
+		}
+	}
+	return
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"github.com/aacfactory/errors"
+	"github.com/aacfactory/forg/processes"
+	"strings"
+	"testing"
+)
+
+func TestDisplay_Done(t *testing.T) {
+	failed := errors.Warning("failed")
+	cases := []struct {
+		name    string
+		results []processes.Result
+		code    int
+		summary string
+	}{
+		{
+			name:    "empty",
+			results: nil,
+			code:    exitOK,
+			summary: "forg: 0 units succeed",
+		},
+		{
+			name: "succeed",
+			results: []processes.Result{
+				{Event: processes.UnitStarted},
+				{Event: processes.UnitFinished},
+				{Event: processes.StepFinished},
+			},
+			code:    exitOK,
+			summary: "forg: 1 units succeed",
+		},
+		{
+			name: "retried",
+			results: []processes.Result{
+				{Event: processes.UnitFinished, Retrying: true, Error: failed},
+				{Event: processes.UnitFinished},
+			},
+			code:    exitOK,
+			summary: "forg: 1 units succeed",
+		},
+		{
+			name: "failed",
+			results: []processes.Result{
+				{Event: processes.UnitFinished, Error: failed},
+				{Event: processes.UnitFinished},
+				{Event: processes.StepFinished, Error: failed},
+				{Event: processes.ProcessFinished, Error: failed},
+			},
+			code:    exitFailed,
+			summary: "forg: 1 of 2 units failed",
+		},
+		{
+			name: "aborted",
+			results: []processes.Result{
+				{Event: processes.UnitFinished, Error: failed},
+				{Event: processes.UnitFinished, Error: processes.ErrAborted},
+			},
+			code:    exitAborted,
+			summary: "forg: aborted",
+		},
+	}
+	for _, output := range []string{textOutput, jsonOutput} {
+		for _, c := range cases {
+			out := bytes.NewBuffer(nil)
+			d := &display{
+				out:      out,
+				output:   output,
+				verbose:  false,
+				live:     false,
+				dirty:    false,
+				failed:   0,
+				aborted:  false,
+				finished: 0,
+			}
+			for _, result := range c.results {
+				d.Show(result)
+			}
+			if code := d.Done(); code != c.code {
+				t.Errorf("%s %s: expect exit code %d, got %d", output, c.name, c.code, code)
+			}
+			if output == textOutput && !strings.HasSuffix(out.String(), c.summary+"\n") {
+				t.Errorf("%s %s: expect summary %q, got %q", output, c.name, c.summary, out.String())
+			}
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/aacfactory/errors"
+	"github.com/aacfactory/forg"
+	"github.com/aacfactory/forg/module"
+	"github.com/aacfactory/forg/processes"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	textOutput = "text"
+	jsonOutput = "json"
+)
+
+const (
+	retryBackoff    = 100 * time.Millisecond
+	maxRetryBackoff = 2 * time.Second
+)
+
+type projectFlags struct {
+	workspace string
+	config    string
+	packages  bool
+	goos      string
+	goarch    string
+	tags      string
+	cache     string
+	parallel  int
+	retry     int
+	verbose   bool
+	output    string
+}
+
+func (pf *projectFlags) register(flags *flag.FlagSet) {
+	flags.StringVar(&pf.workspace, "work", "", "go.work file of the workspace, off disables it, default is found like go command")
+	flags.StringVar(&pf.config, "config", "", "config file, default is forg.yaml, forg.yml or forg.json in project dir")
+	flags.BoolVar(&pf.packages, "packages", false, "build types by go/packages and go/types instead of ast, it needs go command")
+	flags.StringVar(&pf.goos, "goos", "", "GOOS of build constraints, default is go env GOOS")
+	flags.StringVar(&pf.goarch, "goarch", "", "GOARCH of build constraints, default is go env GOARCH")
+	flags.StringVar(&pf.tags, "tags", "", "comma-separated list of build tags, same as -tags of go command")
+	flags.StringVar(&pf.cache, "cache", "", "parse cache dir, off disables it, default is FORGCACHE or forg in user cache dir")
+	flags.IntVar(&pf.parallel, "parallel", 0, "max number of functions which are parsed at the same time, 0 means no limit")
+	flags.IntVar(&pf.retry, "retry", 0, "max attempts of a failed unit, 0 means no retry")
+	flags.BoolVar(&pf.verbose, "v", false, "print every result and error details")
+	flags.StringVar(&pf.output, "o", textOutput, "output format, text or json")
+}
+
+func (pf *projectFlags) validate() (err error) {
+	if pf.parallel < 0 {
+		err = errors.Warning("forg: parallel is invalid").WithMeta("parallel", fmt.Sprintf("%d", pf.parallel))
+		return
+	}
+	if pf.retry < 0 {
+		err = errors.Warning("forg: retry is invalid").WithMeta("retry", fmt.Sprintf("%d", pf.retry))
+		return
+	}
+	pf.output = strings.ToLower(strings.TrimSpace(pf.output))
+	if pf.output != textOutput && pf.output != jsonOutput {
+		err = errors.Warning("forg: output format is invalid").WithMeta("output", pf.output)
+		return
+	}
+	return
+}
+
+func (pf *projectFlags) options() (options []forg.Option) {
+	options = make([]forg.Option, 0, 1)
+	if pf.workspace != "" {
+		options = append(options, forg.WithWorkspace(pf.workspace))
+	}
+	if pf.config != "" {
+		options = append(options, forg.WithConfigFile(pf.config))
+	}
+	if pf.packages {
+		options = append(options, forg.WithPackagesLoader())
+	}
+	if pf.goos != "" {
+		options = append(options, forg.WithGOOS(pf.goos))
+	}
+	if pf.goarch != "" {
+		options = append(options, forg.WithGOARCH(pf.goarch))
+	}
+	cache := pf.cache
+	if cache == "" {
+		// the cache is best effort, so commands work without it when the default dir is unknown
+		cache, _ = module.CacheDir()
+	}
+	if cache != "" {
+		options = append(options, forg.WithCache(cache))
+	}
+	if pf.parallel > 0 {
+		options = append(options, forg.WithMaxParallel(pf.parallel))
+	}
+	if pf.retry > 1 {
+		options = append(options, forg.WithRetry(processes.RetryPolicy{
+			MaxAttempts: pf.retry,
+			Backoff:     processes.ExponentialBackoff(retryBackoff, maxRetryBackoff),
+			Retryable:   nil,
+		}))
+	}
+	if tags := strings.FieldsFunc(pf.tags, func(r rune) bool { return r == ',' || r == ' ' }); len(tags) > 0 {
+		options = append(options, forg.WithTags(tags...))
+	}
+	return
+}
+
+// load loads the project and reports the chosen workspace in verbose mode.
+func (pf *projectFlags) load(dir string, options ...forg.Option) (project *forg.Project, err error) {
+	project, err = forg.Load(dir, options...)
+	if err != nil {
+		return
+	}
+	if pf.verbose {
+		if workspace := project.Workspace(); workspace != "" {
+			_, _ = fmt.Fprintf(os.Stderr, "forg: use workspace %s\n", workspace)
+		} else {
+			_, _ = fmt.Fprintln(os.Stderr, "forg: no workspace")
+		}
+	}
+	return
+}
+
+func parseFlags(flags *flag.FlagSet, args []string) (dir string, code int, ok bool) {
+	flags.SetOutput(os.Stderr)
+	parseErr := flags.Parse(args)
+	if parseErr != nil {
+		if parseErr == flag.ErrHelp {
+			code = exitOK
+			return
+		}
+		code = exitUsage
+		return
+	}
+	switch flags.NArg() {
+	case 0:
+		dir = "."
+		break
+	case 1:
+		dir = flags.Arg(0)
+		break
+	default:
+		_, _ = fmt.Fprintf(os.Stderr, "forg: too many arguments: %s\n", strings.Join(flags.Args(), " "))
+		flags.Usage()
+		code = exitUsage
+		return
+	}
+	ok = true
+	return
+}
+
+func printError(pf *projectFlags, err error) {
+	if pf.verbose {
+		_, _ = fmt.Fprintf(os.Stderr, "%+v\n", err)
+		return
+	}
+	_, _ = fmt.Fprintf(os.Stderr, "forg: %s\n", module.ErrorMessages(err, true))
+}
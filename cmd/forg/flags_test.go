@@ -0,0 +1,46 @@
+package main
+
+import (
+	"flag"
+	"testing"
+)
+
+func TestProjectFlags(t *testing.T) {
+	cases := []struct {
+		name   string
+		args   []string
+		dir    string
+		code   int
+		ok     bool
+		valid  bool
+		output string
+	}{
+		{name: "default", args: nil, dir: ".", code: exitOK, ok: true, valid: true, output: textOutput},
+		{name: "dir", args: []string{"-v", "project"}, dir: "project", code: exitOK, ok: true, valid: true, output: textOutput},
+		{name: "json", args: []string{"-o", " JSON "}, dir: ".", code: exitOK, ok: true, valid: true, output: jsonOutput},
+		{name: "all", args: []string{"-work", "off", "-cache", "off", "-tags", "a,b", "-parallel", "2", "-retry", "3", "."}, dir: ".", code: exitOK, ok: true, valid: true, output: textOutput},
+		{name: "invalid output", args: []string{"-o", "xml"}, dir: ".", code: exitOK, ok: true, valid: false, output: "xml"},
+		{name: "invalid parallel", args: []string{"-parallel", "-1"}, dir: ".", code: exitOK, ok: true, valid: false, output: textOutput},
+		{name: "invalid retry", args: []string{"-retry", "-1"}, dir: ".", code: exitOK, ok: true, valid: false, output: textOutput},
+		{name: "unknown flag", args: []string{"-unknown"}, dir: "", code: exitUsage, ok: false, valid: false, output: ""},
+		{name: "too many arguments", args: []string{"a", "b"}, dir: "", code: exitUsage, ok: false, valid: false, output: ""},
+		{name: "help", args: []string{"-h"}, dir: "", code: exitOK, ok: false, valid: false, output: ""},
+	}
+	for _, c := range cases {
+		pf := &projectFlags{}
+		flags := flag.NewFlagSet(c.name, flag.ContinueOnError)
+		pf.register(flags)
+		dir, code, ok := parseFlags(flags, c.args)
+		if dir != c.dir || code != c.code || ok != c.ok {
+			t.Errorf("%s: expect %q %d %v, got %q %d %v", c.name, c.dir, c.code, c.ok, dir, code, ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		validErr := pf.validate()
+		if (validErr == nil) != c.valid || pf.output != c.output {
+			t.Errorf("%s: expect valid %v and output %q, got %v and %q", c.name, c.valid, c.output, validErr, pf.output)
+		}
+	}
+}
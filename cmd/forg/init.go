@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/aacfactory/forg"
+	"github.com/aacfactory/forg/module"
+	"github.com/aacfactory/forg/scaffolds"
+	"os"
+	"path/filepath"
+)
+
+func runInit(args []string) (code int) {
+	pf := &projectFlags{}
+	flags := flag.NewFlagSet("init", flag.ContinueOnError)
+	flags.BoolVar(&pf.verbose, "v", false, "print every result and error details")
+	flags.StringVar(&pf.output, "o", textOutput, "output format, text or json")
+	dir := flags.String("p", ".", "project dir, it is created when not exist")
+	latest := flags.Bool("latest", false, "require latest versions of fns and errors instead of pinned versions, it needs network")
+	fnsVersion := flags.String("fns-version", "", "version of "+scaffolds.FnsPath)
+	flags.Usage = func() {
+		_, _ = fmt.Fprintln(flags.Output(), "usage: forg init [flags] <module path>")
+		flags.PrintDefaults()
+	}
+	flags.SetOutput(os.Stderr)
+	if parseErr := flags.Parse(args); parseErr != nil {
+		if parseErr == flag.ErrHelp {
+			return
+		}
+		code = exitUsage
+		return
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		code = exitUsage
+		return
+	}
+	if err := pf.validate(); err != nil {
+		printError(pf, err)
+		code = exitUsage
+		return
+	}
+	project, projectErr := scaffolds.NewProject(flags.Arg(0))
+	if projectErr != nil {
+		printError(pf, projectErr)
+		code = exitUsage
+		return
+	}
+	if *latest {
+		for _, require := range project.Requires {
+			version, versionErr := module.LatestVersion(require.Path)
+			if versionErr != nil {
+				_, _ = fmt.Fprintf(os.Stderr, "forg: use pinned %s@%s, get latest version failed: %s\n", require.Path, require.Version, module.ErrorMessages(versionErr, true))
+				continue
+			}
+			project.Require(require.Path, version)
+		}
+	}
+	if *fnsVersion != "" {
+		project.Require(scaffolds.FnsPath, *fnsVersion)
+	}
+	ctx := context.Background()
+	created, initErr := forg.Init(ctx, *dir, project)
+	if initErr != nil {
+		printError(pf, initErr)
+		code = exitFailed
+		return
+	}
+	base, _ := filepath.Abs(*dir)
+	for i, filename := range created {
+		if rel, relErr := filepath.Rel(base, filename); relErr == nil {
+			created[i] = filepath.ToSlash(rel)
+		}
+	}
+	if pf.output == jsonOutput {
+		p, _ := json.Marshal(map[string][]string{"created": created})
+		_, _ = fmt.Fprintf(os.Stdout, "%s\n", p)
+	} else {
+		for _, filename := range created {
+			_, _ = fmt.Fprintf(os.Stdout, "created %s\n", filename)
+		}
+	}
+	// generate code files, then main.go can be built after go mod tidy.
+	p, loadErr := forg.Load(*dir)
+	if loadErr != nil {
+		printError(pf, loadErr)
+		code = exitFailed
+		return
+	}
+	process, codingErr := p.Coding(ctx)
+	if codingErr != nil {
+		printError(pf, codingErr)
+		code = exitFailed
+		return
+	}
+	d := newDisplay(os.Stdout, pf.output, pf.verbose)
+	for result := range process.Start(ctx) {
+		d.Show(result)
+	}
+	code = d.Done()
+	if code == exitOK && pf.output == textOutput {
+		_, _ = fmt.Fprintln(os.Stdout, "forg: run 'go mod tidy' in the project dir to download dependencies")
+	}
+	return
+}
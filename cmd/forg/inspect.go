@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+)
+
+func runInspect(args []string) (code int) {
+	pf := &projectFlags{}
+	flags := flag.NewFlagSet("inspect", flag.ContinueOnError)
+	pf.register(flags)
+	flags.Usage = func() {
+		_, _ = fmt.Fprintln(flags.Output(), "usage: forg inspect [flags] [project dir]")
+		_, _ = fmt.Fprintln(flags.Output(), "the parsed model of services and functions is printed by -o json")
+		flags.PrintDefaults()
+	}
+	dir, parseCode, ok := parseFlags(flags, args)
+	if !ok {
+		code = parseCode
+		return
+	}
+	if err := pf.validate(); err != nil {
+		printError(pf, err)
+		code = exitUsage
+		return
+	}
+	project, loadErr := pf.load(dir, pf.options()...)
+	if loadErr != nil {
+		printError(pf, loadErr)
+		code = exitFailed
+		return
+	}
+	if pf.output == jsonOutput {
+		model, inspectErr := project.Inspect(context.Background())
+		if inspectErr != nil {
+			printError(pf, inspectErr)
+			code = exitFailed
+			return
+		}
+		p, encodeErr := json.MarshalIndent(model, "", "  ")
+		if encodeErr != nil {
+			printError(pf, encodeErr)
+			code = exitFailed
+			return
+		}
+		_, _ = fmt.Fprintf(os.Stdout, "%s\n", p)
+		return
+	}
+	parseErr := project.Mod.Parse(context.Background())
+	if parseErr != nil {
+		printError(pf, parseErr)
+		code = exitFailed
+		return
+	}
+	_, _ = fmt.Fprint(os.Stdout, project.Mod.String())
+	return
+}
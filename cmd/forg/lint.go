@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+func runLint(args []string) (code int) {
+	pf := &projectFlags{}
+	flags := flag.NewFlagSet("lint", flag.ContinueOnError)
+	pf.register(flags)
+	flags.Usage = func() {
+		_, _ = fmt.Fprintln(flags.Output(), "usage: forg lint [flags] [project dir]")
+		flags.PrintDefaults()
+	}
+	dir, parseCode, ok := parseFlags(flags, args)
+	if !ok {
+		code = parseCode
+		return
+	}
+	if err := pf.validate(); err != nil {
+		printError(pf, err)
+		code = exitUsage
+		return
+	}
+	project, loadErr := pf.load(dir, pf.options()...)
+	if loadErr != nil {
+		printError(pf, loadErr)
+		code = exitFailed
+		return
+	}
+	issues, lintErr := project.Lint(context.Background())
+	if lintErr != nil {
+		printError(pf, lintErr)
+		code = exitFailed
+		return
+	}
+	for _, issue := range issues {
+		if rel, relErr := filepath.Rel(project.Mod.Dir, issue.File); relErr == nil {
+			issue.File = filepath.ToSlash(rel)
+		}
+	}
+	if pf.output == jsonOutput {
+		p, _ := json.MarshalIndent(issues, "", "  ")
+		_, _ = fmt.Fprintf(os.Stdout, "%s\n", p)
+	} else {
+		for _, issue := range issues {
+			_, _ = fmt.Fprintln(os.Stdout, issue.String())
+		}
+		if errs := issues.Errors(); len(issues) > 0 {
+			_, _ = fmt.Fprintf(os.Stdout, "forg: %d errors, %d warnings\n", errs, len(issues)-errs)
+		}
+	}
+	if issues.Errors() > 0 {
+		code = exitFailed
+	}
+	return
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+const (
+	exitOK      = 0
+	exitFailed  = 1
+	exitUsage   = 2
+	exitAborted = 130
+)
+
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) (code int)
+}
+
+var commands = []*command{
+	{
+		name:    "codes",
+		summary: "generate fns.go files of services and the deploys file",
+		run:     runCodes,
+	},
+	{
+		name:    "inspect",
+		summary: "print the module and its services",
+		run:     runInspect,
+	},
+	{
+		name:    "lint",
+		summary: "report all problems of services and functions",
+		run:     runLint,
+	},
+	{
+		name:    "clean",
+		summary: "remove generated files",
+		run:     runClean,
+	},
+	{
+		name:    "cache",
+		summary: "manage the parse cache, 'forg cache clean' removes it",
+		run:     runCache,
+	},
+	{
+		name:    "init",
+		summary: "create a fns project skeleton",
+		run:     runInit,
+	},
+	{
+		name:    "new",
+		summary: "create a service or a function skeleton",
+		run:     runNew,
+	},
+}
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+func run(args []string) (code int) {
+	if len(args) == 0 {
+		usage(os.Stderr)
+		code = exitUsage
+		return
+	}
+	name := args[0]
+	if name == "help" || name == "-h" || name == "-help" || name == "--help" {
+		usage(os.Stdout)
+		return
+	}
+	for _, cmd := range commands {
+		if cmd.name == name {
+			code = cmd.run(args[1:])
+			return
+		}
+	}
+	_, _ = fmt.Fprintf(os.Stderr, "forg: unknown command %q\n", name)
+	usage(os.Stderr)
+	code = exitUsage
+	return
+}
+
+func usage(w io.Writer) {
+	_, _ = fmt.Fprintln(w, "usage: forg <command> [flags] [project dir]")
+	_, _ = fmt.Fprintln(w)
+	_, _ = fmt.Fprintln(w, "commands:")
+	for _, cmd := range commands {
+		_, _ = fmt.Fprintf(w, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+	_, _ = fmt.Fprintln(w)
+	_, _ = fmt.Fprintln(w, "run 'forg <command> -h' for flags of a command")
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/aacfactory/forg"
+	"github.com/aacfactory/forg/scaffolds"
+	"os"
+	"path/filepath"
+)
+
+func runNew(args []string) (code int) {
+	if len(args) == 0 {
+		newUsage()
+		code = exitUsage
+		return
+	}
+	switch args[0] {
+	case "service":
+		code = runNewService(args[1:])
+		break
+	case "fn":
+		code = runNewFunction(args[1:])
+		break
+	case "-h", "-help", "--help", "help":
+		newUsage()
+		break
+	default:
+		_, _ = fmt.Fprintf(os.Stderr, "forg: unknown new target %q\n", args[0])
+		newUsage()
+		code = exitUsage
+		break
+	}
+	return
+}
+
+func newUsage() {
+	_, _ = fmt.Fprintln(os.Stderr, "usage: forg new service [flags] <name>")
+	_, _ = fmt.Fprintln(os.Stderr, "       forg new fn [flags] <service> <name>")
+}
+
+type newFlags struct {
+	pf          *projectFlags
+	dir         string
+	title       string
+	description string
+	internal    bool
+}
+
+func (nf *newFlags) register(flags *flag.FlagSet) {
+	nf.pf = &projectFlags{}
+	nf.pf.register(flags)
+	flags.StringVar(&nf.dir, "p", ".", "project dir")
+	flags.StringVar(&nf.title, "title", "", "title annotation, default is the name")
+	flags.StringVar(&nf.description, "description", "", "description annotation")
+	flags.BoolVar(&nf.internal, "internal", false, "mark it as internal")
+}
+
+func (nf *newFlags) parse(flags *flag.FlagSet, args []string, n int) (names []string, code int, ok bool) {
+	flags.SetOutput(os.Stderr)
+	parseErr := flags.Parse(args)
+	if parseErr != nil {
+		if parseErr == flag.ErrHelp {
+			code = exitOK
+			return
+		}
+		code = exitUsage
+		return
+	}
+	if flags.NArg() != n {
+		flags.Usage()
+		code = exitUsage
+		return
+	}
+	if err := nf.pf.validate(); err != nil {
+		printError(nf.pf, err)
+		code = exitUsage
+		return
+	}
+	names = flags.Args()
+	ok = true
+	return
+}
+
+func (nf *newFlags) created(project *forg.Project, filename string) {
+	if rel, relErr := filepath.Rel(project.Mod.Dir, filename); relErr == nil {
+		filename = filepath.ToSlash(rel)
+	}
+	if nf.pf.output == jsonOutput {
+		p, _ := json.Marshal(map[string]string{"created": filename})
+		_, _ = fmt.Fprintf(os.Stdout, "%s\n", p)
+		return
+	}
+	_, _ = fmt.Fprintf(os.Stdout, "created %s\n", filename)
+}
+
+func runNewService(args []string) (code int) {
+	nf := &newFlags{}
+	flags := flag.NewFlagSet("new service", flag.ContinueOnError)
+	nf.register(flags)
+	flags.Usage = func() {
+		_, _ = fmt.Fprintln(flags.Output(), "usage: forg new service [flags] <name>")
+		flags.PrintDefaults()
+	}
+	names, parseCode, ok := nf.parse(flags, args, 1)
+	if !ok {
+		code = parseCode
+		return
+	}
+	project, loadErr := nf.pf.load(nf.dir, nf.pf.options()...)
+	if loadErr != nil {
+		printError(nf.pf, loadErr)
+		code = exitFailed
+		return
+	}
+	filename, newErr := project.NewService(context.Background(), &scaffolds.Service{
+		Name:        names[0],
+		Title:       nf.title,
+		Description: nf.description,
+		Internal:    nf.internal,
+	})
+	if newErr != nil {
+		printError(nf.pf, newErr)
+		code = exitFailed
+		return
+	}
+	nf.created(project, filename)
+	return
+}
+
+func runNewFunction(args []string) (code int) {
+	nf := &newFlags{}
+	flags := flag.NewFlagSet("new fn", flag.ContinueOnError)
+	nf.register(flags)
+	authorization := flags.Bool("authorization", false, "require authorization")
+	flags.Usage = func() {
+		_, _ = fmt.Fprintln(flags.Output(), "usage: forg new fn [flags] <service> <name>")
+		flags.PrintDefaults()
+	}
+	names, parseCode, ok := nf.parse(flags, args, 2)
+	if !ok {
+		code = parseCode
+		return
+	}
+	function, functionErr := scaffolds.NewFunction("", names[1])
+	if functionErr != nil {
+		printError(nf.pf, functionErr)
+		code = exitUsage
+		return
+	}
+	if nf.title != "" {
+		function.Title = nf.title
+	}
+	function.Description = nf.description
+	function.Internal = nf.internal
+	function.Authorization = *authorization
+	project, loadErr := nf.pf.load(nf.dir, nf.pf.options()...)
+	if loadErr != nil {
+		printError(nf.pf, loadErr)
+		code = exitFailed
+		return
+	}
+	filename, newErr := project.NewFunction(context.Background(), names[0], function)
+	if newErr != nil {
+		printError(nf.pf, newErr)
+		code = exitFailed
+		return
+	}
+	nf.created(project, filename)
+	return
+}
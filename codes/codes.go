@@ -1,22 +1,54 @@
 package codes
 
 import (
+	"bytes"
 	"context"
+	"github.com/aacfactory/errors"
+	"github.com/aacfactory/forg/files"
 	"github.com/aacfactory/forg/processes"
 )
 
+const (
+	generatedNote = "NOTE: this file has been automatically generated, DON'T EDIT IT!!!"
+)
+
+func Generated(content []byte) (ok bool) {
+	idx := bytes.IndexByte(content, '\n')
+	if idx < 0 {
+		idx = len(content)
+	}
+	ok = bytes.Contains(content[0:idx], []byte(generatedNote))
+	return
+}
+
 type CodeFile interface {
 	Name() (name string)
+	Render(ctx context.Context) (content []byte, err error)
 	Write(ctx context.Context) (err error)
 }
 
 type CodeFileUnit struct {
-	cf CodeFile
+	cf     CodeFile
+	writer files.Writer
 }
 
 func (unit *CodeFileUnit) Handle(ctx context.Context) (result interface{}, err error) {
-	err = unit.cf.Write(ctx)
-	if err != nil {
+	if unit.writer == nil {
+		err = unit.cf.Write(ctx)
+		if err != nil {
+			return
+		}
+		result = unit.cf.Name()
+		return
+	}
+	content, renderErr := unit.cf.Render(ctx)
+	if renderErr != nil {
+		err = renderErr
+		return
+	}
+	writeErr := unit.writer.WriteFile(unit.cf.Name(), content)
+	if writeErr != nil {
+		err = errors.Warning("forg: code file write failed").WithMeta("file", unit.cf.Name()).WithCause(writeErr)
 		return
 	}
 	result = unit.cf.Name()
@@ -25,6 +57,20 @@ func (unit *CodeFileUnit) Handle(ctx context.Context) (result interface{}, err e
 
 func Unit(file CodeFile) (unit processes.Unit) {
 	return &CodeFileUnit{
-		cf: file,
+		cf:     file,
+		writer: nil,
+	}
+}
+
+// WriterUnit renders the file and writes it by the writer instead of Write of the file.
+func WriterUnit(file CodeFile, writer files.Writer) (unit processes.Unit) {
+	return &CodeFileUnit{
+		cf:     file,
+		writer: writer,
 	}
 }
+
+func writeFile(filename string, content []byte) (err error) {
+	err = files.DiskWriter().WriteFile(filename, content)
+	return
+}
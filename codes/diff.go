@@ -0,0 +1,414 @@
+package codes
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/aacfactory/errors"
+	"github.com/aacfactory/forg/files"
+	"github.com/aacfactory/forg/processes"
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	diffContextLines = 3
+)
+
+type FileDiff struct {
+	Filename string `json:"filename"`
+	Exist    bool   `json:"exist"`
+	Changed  bool   `json:"changed"`
+	Unified  string `json:"unified,omitempty"`
+}
+
+func (diff *FileDiff) String() (s string) {
+	if !diff.Changed {
+		s = fmt.Sprintf("%s: unchanged", diff.Filename)
+		return
+	}
+	s = diff.Unified
+	return
+}
+
+func Diff(ctx context.Context, file CodeFile, base string) (diff *FileDiff, err error) {
+	diff, err = DiffFS(ctx, file, base, files.Disk())
+	return
+}
+
+// DiffFS compares the rendered content with the current file in the file system.
+func DiffFS(ctx context.Context, file CodeFile, base string, fsys fs.FS) (diff *FileDiff, err error) {
+	content, renderErr := file.Render(ctx)
+	if renderErr != nil {
+		err = renderErr
+		return
+	}
+	diff = &FileDiff{
+		Filename: file.Name(),
+		Exist:    false,
+		Changed:  false,
+		Unified:  "",
+	}
+	var current []byte
+	if files.Exist(fsys, diff.Filename) {
+		diff.Exist = true
+		var readErr error
+		current, readErr = files.ReadFile(fsys, diff.Filename)
+		if readErr != nil {
+			err = errors.Warning("forg: diff code file failed").WithMeta("file", diff.Filename).WithCause(readErr)
+			return
+		}
+	}
+	if diff.Exist && bytes.Equal(current, content) {
+		return
+	}
+	diff.Changed = true
+	label := diff.Filename
+	if base != "" {
+		rel, relErr := filepath.Rel(base, diff.Filename)
+		if relErr == nil && !strings.HasPrefix(rel, "..") {
+			label = filepath.ToSlash(rel)
+		}
+	}
+	diff.Unified = UnifiedDiff(label, diff.Exist, current, content)
+	return
+}
+
+type DiffUnit struct {
+	cf   CodeFile
+	base string
+	fsys fs.FS
+}
+
+func (unit *DiffUnit) Handle(ctx context.Context) (result interface{}, err error) {
+	result, err = DiffFS(ctx, unit.cf, unit.base, unit.fsys)
+	return
+}
+
+func DryRunUnit(file CodeFile, base string) (unit processes.Unit) {
+	unit = DryRunUnitFS(file, base, files.Disk())
+	return
+}
+
+// DryRunUnitFS diffs the file with the current file in the file system.
+func DryRunUnitFS(file CodeFile, base string, fsys fs.FS) (unit processes.Unit) {
+	return &DiffUnit{
+		cf:   file,
+		base: base,
+		fsys: fsys,
+	}
+}
+
+func UnifiedDiff(filename string, exist bool, current []byte, content []byte) (s string) {
+	a := splitLines(current)
+	b := splitLines(content)
+	edits := diffLines(a, b)
+	buf := bytes.NewBuffer(make([]byte, 0, 1024))
+	if exist {
+		_, _ = buf.WriteString(fmt.Sprintf("--- a/%s\n", filename))
+	} else {
+		_, _ = buf.WriteString("--- /dev/null\n")
+	}
+	_, _ = buf.WriteString(fmt.Sprintf("+++ b/%s\n", filename))
+	for _, hunk := range makeHunks(edits) {
+		_, _ = buf.WriteString(fmt.Sprintf("@@ -%s +%s @@\n", hunkRange(hunk.aStart, hunk.aLen), hunkRange(hunk.bStart, hunk.bLen)))
+		for _, e := range hunk.edits {
+			line := ""
+			switch e.op {
+			case ' ':
+				line = a[e.a]
+				break
+			case '-':
+				line = a[e.a]
+				break
+			case '+':
+				line = b[e.b]
+				break
+			}
+			_ = buf.WriteByte(e.op)
+			if strings.HasSuffix(line, "\n") {
+				_, _ = buf.WriteString(line)
+			} else {
+				_, _ = buf.WriteString(line)
+				_, _ = buf.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+	}
+	s = buf.String()
+	return
+}
+
+func splitLines(p []byte) (lines []string) {
+	lines = make([]string, 0, bytes.Count(p, []byte{'\n'})+1)
+	for len(p) > 0 {
+		idx := bytes.IndexByte(p, '\n')
+		if idx < 0 {
+			lines = append(lines, string(p))
+			break
+		}
+		lines = append(lines, string(p[0:idx+1]))
+		p = p[idx+1:]
+	}
+	return
+}
+
+type lineEdit struct {
+	op byte
+	a  int
+	b  int
+}
+
+// diffLines is the linear space myers algorithm, it returns edits from a to b.
+// Identical content, an empty a and an empty b are returned without searching,
+// others are split by middle snakes, so the memory is O(N+M) instead of O((N+M)D) of keeping every v.
+func diffLines(a []string, b []string) (edits []lineEdit) {
+	d := &differ{
+		a:     a,
+		b:     b,
+		edits: make([]lineEdit, 0, len(a)+len(b)),
+		v1:    nil,
+		v2:    nil,
+	}
+	d.compare(0, len(a), 0, len(b))
+	edits = d.edits
+	sortChanges(edits)
+	return
+}
+
+// sortChanges puts deletions before insertions in every run of changes, as diff tools show them.
+func sortChanges(edits []lineEdit) {
+	for i := 0; i < len(edits); {
+		if edits[i].op == ' ' {
+			i++
+			continue
+		}
+		x, y := edits[i].a, edits[i].b
+		end := i
+		deleted := 0
+		for end < len(edits) && edits[end].op != ' ' {
+			if edits[end].op == '-' {
+				deleted++
+			}
+			end++
+		}
+		for j := i; j < end; j++ {
+			if j-i < deleted {
+				edits[j] = lineEdit{op: '-', a: x + j - i, b: y}
+			} else {
+				edits[j] = lineEdit{op: '+', a: x + deleted, b: y + j - i - deleted}
+			}
+		}
+		i = end
+	}
+}
+
+type differ struct {
+	a     []string
+	b     []string
+	edits []lineEdit
+	v1    []int
+	v2    []int
+}
+
+// compare trims the common prefix and suffix, so identical ranges and empty ranges are done without bisect.
+func (d *differ) compare(aLo int, aHi int, bLo int, bHi int) {
+	for aLo < aHi && bLo < bHi && d.a[aLo] == d.b[bLo] {
+		d.edits = append(d.edits, lineEdit{op: ' ', a: aLo, b: bLo})
+		aLo++
+		bLo++
+	}
+	suffix := 0
+	for aHi > aLo && bHi > bLo && d.a[aHi-1] == d.b[bHi-1] {
+		aHi--
+		bHi--
+		suffix++
+	}
+	if aLo == aHi {
+		for y := bLo; y < bHi; y++ {
+			d.edits = append(d.edits, lineEdit{op: '+', a: aLo, b: y})
+		}
+	} else if bLo == bHi {
+		for x := aLo; x < aHi; x++ {
+			d.edits = append(d.edits, lineEdit{op: '-', a: x, b: bLo})
+		}
+	} else if x, y, ok := d.bisect(aLo, aHi, bLo, bHi); ok {
+		d.compare(aLo, x, bLo, y)
+		d.compare(x, aHi, y, bHi)
+	} else {
+		for x := aLo; x < aHi; x++ {
+			d.edits = append(d.edits, lineEdit{op: '-', a: x, b: bLo})
+		}
+		for y := bLo; y < bHi; y++ {
+			d.edits = append(d.edits, lineEdit{op: '+', a: aHi, b: y})
+		}
+	}
+	for i := 0; i < suffix; i++ {
+		d.edits = append(d.edits, lineEdit{op: ' ', a: aHi + i, b: bHi + i})
+	}
+}
+
+// bisect finds the middle snake of a[aLo:aHi] and b[bLo:bHi] by searching forward and reverse paths at the same time,
+// x and y is the point where the paths overlap, which splits the problem into two smaller ones.
+func (d *differ) bisect(aLo int, aHi int, bLo int, bHi int) (x int, y int, ok bool) {
+	n := aHi - aLo
+	m := bHi - bLo
+	maxD := (n + m + 1) / 2
+	offset := maxD
+	size := 2*maxD + 2
+	if d.v1 == nil {
+		full := 2*((len(d.a)+len(d.b)+1)/2) + 2
+		d.v1 = make([]int, full)
+		d.v2 = make([]int, full)
+	}
+	v1 := d.v1[0:size]
+	v2 := d.v2[0:size]
+	for i := range v1 {
+		v1[i] = -1
+		v2[i] = -1
+	}
+	v1[offset+1] = 0
+	v2[offset+1] = 0
+	delta := n - m
+	front := delta%2 != 0
+	k1Start, k1End, k2Start, k2End := 0, 0, 0, 0
+	for step := 0; step < maxD; step++ {
+		for k1 := -step + k1Start; k1 <= step-k1End; k1 += 2 {
+			k1Offset := offset + k1
+			x1 := 0
+			if k1 == -step || (k1 != step && v1[k1Offset-1] < v1[k1Offset+1]) {
+				x1 = v1[k1Offset+1]
+			} else {
+				x1 = v1[k1Offset-1] + 1
+			}
+			y1 := x1 - k1
+			for x1 < n && y1 < m && d.a[aLo+x1] == d.b[bLo+y1] {
+				x1++
+				y1++
+			}
+			v1[k1Offset] = x1
+			if x1 > n {
+				k1End += 2
+			} else if y1 > m {
+				k1Start += 2
+			} else if front {
+				k2Offset := offset + delta - k1
+				if k2Offset >= 0 && k2Offset < size && v2[k2Offset] != -1 && x1 >= n-v2[k2Offset] {
+					x, y, ok = aLo+x1, bLo+y1, true
+					return
+				}
+			}
+		}
+		for k2 := -step + k2Start; k2 <= step-k2End; k2 += 2 {
+			k2Offset := offset + k2
+			x2 := 0
+			if k2 == -step || (k2 != step && v2[k2Offset-1] < v2[k2Offset+1]) {
+				x2 = v2[k2Offset+1]
+			} else {
+				x2 = v2[k2Offset-1] + 1
+			}
+			y2 := x2 - k2
+			for x2 < n && y2 < m && d.a[aHi-x2-1] == d.b[bHi-y2-1] {
+				x2++
+				y2++
+			}
+			v2[k2Offset] = x2
+			if x2 > n {
+				k2End += 2
+			} else if y2 > m {
+				k2Start += 2
+			} else if !front {
+				k1Offset := offset + delta - k2
+				if k1Offset >= 0 && k1Offset < size && v1[k1Offset] != -1 {
+					x1 := v1[k1Offset]
+					y1 := offset + x1 - k1Offset
+					if x1 >= n-x2 {
+						x, y, ok = aLo+x1, bLo+y1, true
+						return
+					}
+				}
+			}
+		}
+	}
+	return
+}
+
+type diffHunk struct {
+	aStart int
+	aLen   int
+	bStart int
+	bLen   int
+	edits  []lineEdit
+}
+
+func makeHunks(edits []lineEdit) (hunks []*diffHunk) {
+	hunks = make([]*diffHunk, 0, 1)
+	i := 0
+	for i < len(edits) {
+		if edits[i].op == ' ' {
+			i++
+			continue
+		}
+		start := i - diffContextLines
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for end < len(edits) {
+			if edits[end].op != ' ' {
+				end++
+				continue
+			}
+			same := 0
+			for end+same < len(edits) && edits[end+same].op == ' ' {
+				same++
+			}
+			if end+same < len(edits) && same <= 2*diffContextLines {
+				end = end + same
+				continue
+			}
+			if same > diffContextLines {
+				same = diffContextLines
+			}
+			end = end + same
+			break
+		}
+		hunk := &diffHunk{
+			aStart: edits[start].a,
+			aLen:   0,
+			bStart: edits[start].b,
+			bLen:   0,
+			edits:  edits[start:end],
+		}
+		for _, e := range hunk.edits {
+			switch e.op {
+			case ' ':
+				hunk.aLen++
+				hunk.bLen++
+				break
+			case '-':
+				hunk.aLen++
+				break
+			case '+':
+				hunk.bLen++
+				break
+			}
+		}
+		hunks = append(hunks, hunk)
+		i = end
+	}
+	return
+}
+
+func hunkRange(start int, length int) (s string) {
+	if length == 0 {
+		s = fmt.Sprintf("%d,0", start)
+		return
+	}
+	if length == 1 {
+		s = fmt.Sprintf("%d", start+1)
+		return
+	}
+	s = fmt.Sprintf("%d,%d", start+1, length)
+	return
+}
@@ -0,0 +1,76 @@
+package codes_test
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/aacfactory/forg/codes"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	current := "package users\n\nconst (\n\t_name = \"users\"\n\t_getFn = \"get\"\n)\n\nfunc a() {}\nfunc b() {}\nfunc c() {}\nfunc d() {}\nfunc e() {}\nfunc f() {}\nfunc g() {}\n"
+	content := "package users\n\nconst (\n\t_name = \"users\"\n\t_getFn = \"get\"\n\t_listFn = \"list\"\n)\n\nfunc a() {}\nfunc b() {}\nfunc c() {}\nfunc d() {}\nfunc e() {}\nfunc f() {}\nfunc h() {}\n"
+	s := codes.UnifiedDiff("modules/users/fns.go", true, []byte(current), []byte(content))
+	expected := "--- a/modules/users/fns.go\n" +
+		"+++ b/modules/users/fns.go\n" +
+		"@@ -3,6 +3,7 @@\n" +
+		" const (\n" +
+		" \t_name = \"users\"\n" +
+		" \t_getFn = \"get\"\n" +
+		"+\t_listFn = \"list\"\n" +
+		" )\n" +
+		" \n" +
+		" func a() {}\n" +
+		"@@ -11,4 +12,4 @@\n" +
+		" func d() {}\n" +
+		" func e() {}\n" +
+		" func f() {}\n" +
+		"-func g() {}\n" +
+		"+func h() {}\n"
+	if s != expected {
+		t.Errorf("unexpected diff:\n%s", s)
+		return
+	}
+}
+
+func TestUnifiedDiffOfNewFile(t *testing.T) {
+	s := codes.UnifiedDiff("modules/fns.go", false, nil, []byte("package modules\n\nfunc services() {}"))
+	expected := "--- /dev/null\n" +
+		"+++ b/modules/fns.go\n" +
+		"@@ -0,0 +1,3 @@\n" +
+		"+package modules\n" +
+		"+\n" +
+		"+func services() {}\n" +
+		"\\ No newline at end of file\n"
+	if s != expected {
+		t.Errorf("unexpected diff:\n%s", s)
+		return
+	}
+}
+
+func TestUnifiedDiffOfLargeFile(t *testing.T) {
+	lines := 5000
+	current := bytes.NewBuffer(make([]byte, 0, 32*lines))
+	content := bytes.NewBuffer(make([]byte, 0, 32*lines))
+	for i := 0; i < lines; i++ {
+		_, _ = fmt.Fprintf(current, "func a%d() {}\n", i)
+		_, _ = fmt.Fprintf(content, "func b%d() {}\n", i)
+	}
+	stats := runtime.MemStats{}
+	runtime.ReadMemStats(&stats)
+	before := stats.TotalAlloc
+	s := codes.UnifiedDiff("modules/fns.go", true, current.Bytes(), content.Bytes())
+	runtime.ReadMemStats(&stats)
+	allocated := stats.TotalAlloc - before
+	if strings.Count(s, "\n-func a") != lines || strings.Count(s, "\n+func b") != lines {
+		t.Errorf("unexpected diff of large file")
+		return
+	}
+	// keeping every v of myers costs about (2N)^2 ints, that is gigabytes here.
+	if allocated > 16<<20 {
+		t.Errorf("diff of large file allocated %d bytes", allocated)
+		return
+	}
+}
@@ -5,30 +5,32 @@ import (
 	"context"
 	"fmt"
 	"github.com/aacfactory/errors"
+	"github.com/aacfactory/forg/configs"
 	"github.com/aacfactory/forg/module"
 	"github.com/aacfactory/gcg"
-	"os"
 	"path/filepath"
 	"strings"
 )
 
-func NewServiceFile(service *module.Service) (file CodeFile) {
+func NewServiceFile(service *module.Service, config *configs.Config) (file CodeFile) {
 	file = &ServiceFile{
 		service: service,
+		config:  config,
 	}
 	return
 }
 
 type ServiceFile struct {
 	service *module.Service
+	config  *configs.Config
 }
 
 func (s *ServiceFile) Name() (name string) {
-	name = filepath.ToSlash(filepath.Join(s.service.Dir, "fns.go"))
+	name = filepath.ToSlash(filepath.Join(s.service.Dir, s.config.Output))
 	return
 }
 
-func (s *ServiceFile) Write(ctx context.Context) (err error) {
+func (s *ServiceFile) Render(ctx context.Context) (content []byte, err error) {
 	if ctx.Err() != nil {
 		err = errors.Warning("forg: service write failed").
 			WithMeta("kind", "service").WithMeta("service", s.service.Name).
@@ -37,7 +39,7 @@ func (s *ServiceFile) Write(ctx context.Context) (err error) {
 	}
 
 	file := gcg.NewFileWithoutNote(s.service.Path[strings.LastIndex(s.service.Path, "/")+1:])
-	file.FileComments("NOTE: this file has been automatically generated, DON'T EDIT IT!!!\n")
+	file.FileComments(generatedNote + "\n")
 
 	packages, importsErr := s.importsCode(ctx)
 	if importsErr != nil {
@@ -88,38 +90,21 @@ func (s *ServiceFile) Write(ctx context.Context) (err error) {
 			WithCause(renderErr)
 		return
 	}
-	writer, openErr := os.OpenFile(s.Name(), os.O_CREATE|os.O_TRUNC|os.O_RDWR|os.O_SYNC, 0600)
-	if openErr != nil {
-		err = errors.Warning("forg: code file write failed").
-			WithMeta("kind", "service").WithMeta("service", s.service.Name).WithMeta("file", s.Name()).
-			WithCause(openErr)
-		return
-	}
-	n := 0
-	bodyLen := buf.Len()
-	body := buf.Bytes()
-	for n < bodyLen {
-		nn, writeErr := writer.Write(body[n:])
-		if writeErr != nil {
-			err = errors.Warning("forg: code file write failed").
-				WithMeta("kind", "service").WithMeta("service", s.service.Name).WithMeta("file", s.Name()).
-				WithCause(writeErr)
-			return
-		}
-		n += nn
-	}
-	syncErr := writer.Sync()
-	if syncErr != nil {
-		err = errors.Warning("forg: code file write failed").
-			WithMeta("kind", "service").WithMeta("service", s.service.Name).WithMeta("file", s.Name()).
-			WithCause(syncErr)
+	content = buf.Bytes()
+	return
+}
+
+func (s *ServiceFile) Write(ctx context.Context) (err error) {
+	content, renderErr := s.Render(ctx)
+	if renderErr != nil {
+		err = renderErr
 		return
 	}
-	closeErr := writer.Close()
-	if closeErr != nil {
+	writeErr := writeFile(s.Name(), content)
+	if writeErr != nil {
 		err = errors.Warning("forg: code file write failed").
 			WithMeta("kind", "service").WithMeta("service", s.service.Name).WithMeta("file", s.Name()).
-			WithCause(closeErr)
+			WithCause(writeErr)
 		return
 	}
 	return
@@ -340,7 +325,7 @@ func (s *ServiceFile) serviceTypeCode(ctx context.Context) (code gcg.Code, err e
 		return
 	}
 	abstractFieldCode := gcg.StructField("")
-	abstractFieldCode.Type(gcg.Token("service.Abstract", gcg.NewPackage("github.com/aacfactory/fns/service")))
+	abstractFieldCode.Type(gcg.Token("service.Abstract", gcg.NewPackage(s.config.Imports.Service)))
 	serviceStructCode := gcg.Struct()
 	serviceStructCode.AddField(abstractFieldCode)
 	code = gcg.Type("_service_", serviceStructCode.Build())
@@ -359,7 +344,7 @@ func (s *ServiceFile) serviceHandleCode(ctx context.Context) (code gcg.Code, err
 	handleFnCode.Name("Handle")
 	handleFnCode.AddParam("ctx", gcg.QualifiedIdent(gcg.NewPackage("context"), "Context"))
 	handleFnCode.AddParam("fn", gcg.String())
-	handleFnCode.AddParam("argument", gcg.QualifiedIdent(gcg.NewPackage("github.com/aacfactory/fns/service"), "Argument"))
+	handleFnCode.AddParam("argument", gcg.QualifiedIdent(gcg.NewPackage(s.config.Imports.Service), "Argument"))
 	handleFnCode.AddResult("v", gcg.Token("interface{}"))
 	handleFnCode.AddResult("err", gcg.QualifiedIdent(gcg.NewPackage("github.com/aacfactory/errors"), "CodeError"))
 
@@ -380,7 +365,7 @@ func (s *ServiceFile) serviceHandleCode(ctx context.Context) (code gcg.Code, err
 			// authorization
 			if function.Authorization() {
 				functionCode.Token("// verify authorizations").Line()
-				functionCode.Token("err = authorizations.ParseContext(ctx)", gcg.NewPackage("github.com/aacfactory/fns/service/builtin/authorizations")).Line()
+				functionCode.Token("err = authorizations.ParseContext(ctx)", gcg.NewPackage(s.config.Imports.Authorizations)).Line()
 				functionCode.Token("if err != nil {").Line()
 				functionCode.Tab().Break().Line()
 				functionCode.Token("}").Line()
@@ -388,7 +373,7 @@ func (s *ServiceFile) serviceHandleCode(ctx context.Context) (code gcg.Code, err
 			// permission
 			if function.Permission() {
 				functionCode.Token("// verify permissions").Line()
-				functionCode.Token("err = permissions.EnforceContext(ctx, _name, fn)", gcg.NewPackage("github.com/aacfactory/fns/service/builtin/permissions")).Line()
+				functionCode.Token("err = permissions.EnforceContext(ctx, _name, fn)", gcg.NewPackage(s.config.Imports.Permissions)).Line()
 				functionCode.Token("if err != nil {").Line()
 				functionCode.Tab().Break().Line()
 				functionCode.Token("}").Line()
@@ -422,7 +407,7 @@ func (s *ServiceFile) serviceHandleCode(ctx context.Context) (code gcg.Code, err
 				functionCode.Token("}").Line()
 				// param validation
 				if title, has := function.Validation(); has {
-					functionCode.Token(fmt.Sprintf("err = validators.ValidateWithErrorTitle(param, \"%s\")", title), gcg.NewPackage("github.com/aacfactory/fns/service/validators")).Line()
+					functionCode.Token(fmt.Sprintf("err = validators.ValidateWithErrorTitle(param, \"%s\")", title), gcg.NewPackage(s.config.Imports.Validators)).Line()
 					functionCode.Token("if err != nil {").Line()
 					functionCode.Tab().Break().Line()
 					functionCode.Token("}").Line()
@@ -456,12 +441,12 @@ func (s *ServiceFile) serviceHandleCode(ctx context.Context) (code gcg.Code, err
 					return
 				}
 				functionExecCode.Token("// use sql database").Line()
-				functionExecCode.Token(fmt.Sprintf("ctx = sql.WithOptions(ctx, sql.Database(\"%s\"))", db), gcg.NewPackage("github.com/aacfactory/fns-contrib/databases/sql")).Line()
+				functionExecCode.Token(fmt.Sprintf("ctx = sql.WithOptions(ctx, sql.Database(\"%s\"))", db), gcg.NewPackage(s.config.Imports.SQL)).Line()
 			}
 			// transactional
 			if function.Transactional() {
 				functionExecCode.Token("// sql begin transaction").Line()
-				functionExecCode.Token("beginTransactionErr := sql.BeginTransaction(ctx)", gcg.NewPackage("github.com/aacfactory/fns-contrib/databases/sql")).Line()
+				functionExecCode.Token("beginTransactionErr := sql.BeginTransaction(ctx)", gcg.NewPackage(s.config.Imports.SQL)).Line()
 				functionExecCode.Token("if beginTransactionErr != nil {").Line()
 				functionExecCode.Tab().Token(fmt.Sprintf("err = errors.Warning(\"%s: begin sql transaction failed\").WithCause(beginTransactionErr)", s.service.Name)).Line()
 				functionExecCode.Tab().Return().Line()
@@ -481,9 +466,9 @@ func (s *ServiceFile) serviceHandleCode(ctx context.Context) (code gcg.Code, err
 			if function.Transactional() {
 				functionExecCode.Token("// sql commit transaction").Line()
 				functionExecCode.Token("if err == nil {").Line()
-				functionExecCode.Tab().Token("commitTransactionErr := sql.CommitTransaction(ctx)", gcg.NewPackage("github.com/aacfactory/fns-contrib/databases/sql")).Line()
+				functionExecCode.Tab().Token("commitTransactionErr := sql.CommitTransaction(ctx)", gcg.NewPackage(s.config.Imports.SQL)).Line()
 				functionExecCode.Tab().Token("if commitTransactionErr != nil {").Line()
-				functionExecCode.Tab().Tab().Token("_ = sql.RollbackTransaction(ctx)", gcg.NewPackage("github.com/aacfactory/fns-contrib/databases/sql")).Line()
+				functionExecCode.Tab().Tab().Token("_ = sql.RollbackTransaction(ctx)", gcg.NewPackage(s.config.Imports.SQL)).Line()
 				functionExecCode.Tab().Tab().Token(fmt.Sprintf("err = errors.ServiceError(\"%s: commit sql transaction failed\").WithCause(commitTransactionErr)", s.service.Name)).Line()
 				functionExecCode.Tab().Tab().Return().Line()
 				functionExecCode.Tab().Token("}").Line()
@@ -529,7 +514,7 @@ func (s *ServiceFile) serviceDocumentCode(ctx context.Context) (code gcg.Code, e
 	docFnCode := gcg.Func()
 	docFnCode.Receiver("svc", gcg.Star().Ident("_service_"))
 	docFnCode.Name("Document")
-	docFnCode.AddResult("doc", gcg.QualifiedIdent(gcg.NewPackage("github.com/aacfactory/fns/service"), "Document"))
+	docFnCode.AddResult("doc", gcg.QualifiedIdent(gcg.NewPackage(s.config.Imports.Service), "Document"))
 	body := gcg.Statements()
 	if !s.service.Internal {
 		fnCodes := make([]gcg.Code, 0, 1)
@@ -595,7 +580,7 @@ func (s *ServiceFile) serviceDocumentCode(ctx context.Context) (code gcg.Code, e
 			fnCodes = append(fnCodes, fnCode)
 		}
 		if len(fnCodes) > 0 {
-			body.Token(fmt.Sprintf("document := documents.NewService(_name, \"%s\", svc.AppVersion())", s.service.Description), gcg.NewPackage("github.com/aacfactory/fns/service/documents")).Line()
+			body.Token(fmt.Sprintf("document := documents.NewService(_name, \"%s\", svc.AppVersion())", s.service.Description), gcg.NewPackage(s.config.Imports.Documents)).Line()
 			for _, fnCode := range fnCodes {
 				body.Add(fnCode).Line()
 			}
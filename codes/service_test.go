@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"github.com/aacfactory/forg/codes"
+	"github.com/aacfactory/forg/configs"
 	"github.com/aacfactory/forg/module"
 	"testing"
 )
@@ -38,7 +39,7 @@ func TestNewServiceFile(t *testing.T) {
 		}
 	}
 	for _, service := range services {
-		sf := codes.NewServiceFile(service)
+		sf := codes.NewServiceFile(service, configs.Default())
 		writeErr := sf.Write(ctx)
 		if writeErr != nil {
 			t.Errorf("%+v", writeErr)
@@ -46,7 +47,7 @@ func TestNewServiceFile(t *testing.T) {
 		}
 	}
 
-	deploys := codes.NewDeploysFile(`D:\studio\workspace\go\src\github.com\aacfactory\fns-example\standalone\modules`, services)
+	deploys := codes.NewDeploysFile(`D:\studio\workspace\go\src\github.com\aacfactory\fns-example\standalone\modules`, services, configs.Default())
 	writeErr := deploys.Write(ctx)
 	if writeErr != nil {
 		t.Errorf("%+v", writeErr)
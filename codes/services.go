@@ -5,16 +5,17 @@ import (
 	"context"
 	"fmt"
 	"github.com/aacfactory/errors"
+	"github.com/aacfactory/forg/configs"
 	"github.com/aacfactory/forg/module"
 	"github.com/aacfactory/gcg"
-	"os"
 	"path/filepath"
 )
 
-func NewDeploysFile(dir string, services module.Services) (file CodeFile) {
+func NewDeploysFile(dir string, services module.Services, config *configs.Config) (file CodeFile) {
 	file = &DeploysFile{
-		filename: filepath.ToSlash(filepath.Join(dir, "fns.go")),
+		filename: filepath.ToSlash(filepath.Join(dir, config.Output)),
 		services: services,
+		config:   config,
 	}
 	return
 }
@@ -22,6 +23,7 @@ func NewDeploysFile(dir string, services module.Services) (file CodeFile) {
 type DeploysFile struct {
 	filename string
 	services module.Services
+	config   *configs.Config
 }
 
 func (s *DeploysFile) Name() (name string) {
@@ -29,10 +31,7 @@ func (s *DeploysFile) Name() (name string) {
 	return
 }
 
-func (s *DeploysFile) Write(ctx context.Context) (err error) {
-	if s.filename == "" {
-		return
-	}
+func (s *DeploysFile) Render(ctx context.Context) (content []byte, err error) {
 	if ctx.Err() != nil {
 		err = errors.Warning("forg: services write failed").
 			WithMeta("kind", "services").WithMeta("file", s.Name()).
@@ -40,12 +39,12 @@ func (s *DeploysFile) Write(ctx context.Context) (err error) {
 		return
 	}
 
-	file := gcg.NewFileWithoutNote("modules")
-	file.FileComments("NOTE: this file has been automatically generated, DON'T EDIT IT!!!\n")
+	file := gcg.NewFileWithoutNote(s.config.Package)
+	file.FileComments(generatedNote + "\n")
 
 	fn := gcg.Func()
 	fn.Name("services")
-	fn.AddResult("v", gcg.Token("[]service.Service", gcg.NewPackage("github.com/aacfactory/fns/service")))
+	fn.AddResult("v", gcg.Token("[]service.Service", gcg.NewPackage(s.config.Imports.Service)))
 	body := gcg.Statements()
 	if s.services != nil && s.services.Len() > 0 {
 		body.Token("v = []service.Service{").Line()
@@ -67,40 +66,24 @@ func (s *DeploysFile) Write(ctx context.Context) (err error) {
 			WithCause(renderErr)
 		return
 	}
+	content = buf.Bytes()
+	return
+}
 
-	writer, openErr := os.OpenFile(s.Name(), os.O_CREATE|os.O_TRUNC|os.O_RDWR|os.O_SYNC, 0600)
-	if openErr != nil {
-		err = errors.Warning("forg: services code file write failed").
-			WithMeta("kind", "services").WithMeta("file", s.Name()).
-			WithCause(openErr)
+func (s *DeploysFile) Write(ctx context.Context) (err error) {
+	if s.filename == "" {
 		return
 	}
-
-	n := 0
-	bodyLen := buf.Len()
-	content := buf.Bytes()
-	for n < bodyLen {
-		nn, writeErr := writer.Write(content[n:])
-		if writeErr != nil {
-			err = errors.Warning("forg: services code file write failed").
-				WithMeta("kind", "services").WithMeta("file", s.Name()).
-				WithCause(writeErr)
-			return
-		}
-		n += nn
-	}
-	syncErr := writer.Sync()
-	if syncErr != nil {
-		err = errors.Warning("forg: services code file write failed").
-			WithMeta("kind", "services").WithMeta("file", s.Name()).
-			WithCause(syncErr)
+	content, renderErr := s.Render(ctx)
+	if renderErr != nil {
+		err = renderErr
 		return
 	}
-	closeErr := writer.Close()
-	if closeErr != nil {
+	writeErr := writeFile(s.Name(), content)
+	if writeErr != nil {
 		err = errors.Warning("forg: services code file write failed").
 			WithMeta("kind", "services").WithMeta("file", s.Name()).
-			WithCause(closeErr)
+			WithCause(writeErr)
 		return
 	}
 	return
@@ -0,0 +1,51 @@
+package forg_test
+
+import (
+	"context"
+	"github.com/aacfactory/forg"
+	"github.com/aacfactory/forg/files"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoad_Config(t *testing.T) {
+	dir := copyProject(t, filepath.Join("testdata", "standalone"))
+	config := "{\"output\": \"fns_gen.go\", \"generators\": {\"deploys\": false}}"
+	if err := os.WriteFile(filepath.Join(dir, "forg.json"), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	p, loadErr := forg.Load(dir)
+	if loadErr != nil {
+		t.Fatalf("%+v", loadErr)
+	}
+	if p.Config.Output != "fns_gen.go" || p.Config.Enabled("deploys") {
+		t.Fatalf("unexpected config: %+v", p.Config)
+	}
+	process, codingErr := p.Coding(context.TODO())
+	if codingErr != nil {
+		t.Fatalf("%+v", codingErr)
+	}
+	for result := range process.Start(context.TODO()) {
+		if result.Error != nil {
+			t.Fatalf("%+v", result.Error)
+		}
+	}
+	if !files.ExistFile(filepath.Join(dir, "modules", "users", "fns_gen.go")) {
+		t.Error("service file was not written with output name")
+	}
+	if files.ExistFile(filepath.Join(dir, "modules", "fns_gen.go")) {
+		t.Error("deploys file must not be written when the generator is disabled")
+	}
+
+	p, loadErr = forg.Load(dir, forg.WithGenerator("deploys", true))
+	if loadErr != nil {
+		t.Fatalf("%+v", loadErr)
+	}
+	if !p.Config.Enabled("deploys") {
+		t.Error("options must override config file")
+	}
+	if _, loadErr = forg.Load(dir, forg.WithGenerator("unknown", true)); loadErr == nil {
+		t.Error("unknown generator must be rejected")
+	}
+}
@@ -0,0 +1,206 @@
+package configs
+
+import (
+	"encoding/json"
+	"github.com/aacfactory/errors"
+	"github.com/aacfactory/forg/files"
+	"gopkg.in/yaml.v3"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	ServicesGenerator = "services"
+	DeploysGenerator  = "deploys"
+)
+
+var (
+	// Filenames are config filenames which are searched in project dir in order.
+	Filenames = []string{"forg.yaml", "forg.yml", "forg.json"}
+)
+
+type Imports struct {
+	Service        string `json:"service" yaml:"service"`
+	Documents      string `json:"documents" yaml:"documents"`
+	Authorizations string `json:"authorizations" yaml:"authorizations"`
+	Permissions    string `json:"permissions" yaml:"permissions"`
+	Validators     string `json:"validators" yaml:"validators"`
+	SQL            string `json:"sql" yaml:"sql"`
+}
+
+type Config struct {
+	// Modules is the dir of services, relative to project dir.
+	Modules string `json:"modules" yaml:"modules"`
+	// Output is the filename of generated files.
+	Output string `json:"output" yaml:"output"`
+	// Package is the package name of the deploys file, default is the base name of modules dir.
+	Package    string          `json:"package" yaml:"package"`
+	Imports    Imports         `json:"imports" yaml:"imports"`
+	Generators map[string]bool `json:"generators" yaml:"generators"`
+}
+
+func Default() (config *Config) {
+	config = &Config{
+		Modules: "modules",
+		Output:  "fns.go",
+		Package: "modules",
+		Imports: Imports{
+			Service:        "github.com/aacfactory/fns/service",
+			Documents:      "github.com/aacfactory/fns/service/documents",
+			Authorizations: "github.com/aacfactory/fns/service/builtin/authorizations",
+			Permissions:    "github.com/aacfactory/fns/service/builtin/permissions",
+			Validators:     "github.com/aacfactory/fns/service/validators",
+			SQL:            "github.com/aacfactory/fns-contrib/databases/sql",
+		},
+		Generators: make(map[string]bool),
+	}
+	return
+}
+
+// Find returns the config file in dir, filename is empty when not found.
+func Find(dir string) (filename string) {
+	filename = FindFS(files.Disk(), dir)
+	return
+}
+
+// FindFS returns the config file in dir of the file system, see Find.
+func FindFS(fsys fs.FS, dir string) (filename string) {
+	for _, name := range Filenames {
+		candidate := filepath.Join(dir, name)
+		if files.Exist(fsys, candidate) {
+			filename = candidate
+			return
+		}
+	}
+	return
+}
+
+// Read reads config file, unset values are not filled, see Fill.
+func Read(filename string) (config *Config, err error) {
+	config, err = ReadFS(files.Disk(), filename)
+	return
+}
+
+// ReadFS reads config file of the file system, see Read.
+func ReadFS(fsys fs.FS, filename string) (config *Config, err error) {
+	p, readErr := files.ReadFile(fsys, filename)
+	if readErr != nil {
+		err = errors.Warning("forg: read config failed").WithCause(readErr).WithMeta("file", filename)
+		return
+	}
+	config = &Config{}
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".json":
+		err = json.Unmarshal(p, config)
+		break
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(p, config)
+		break
+	default:
+		err = errors.Warning("forg: unsupported config file type")
+		break
+	}
+	if err != nil {
+		err = errors.Warning("forg: read config failed").WithCause(err).WithMeta("file", filename)
+		config = nil
+		return
+	}
+	return
+}
+
+// Fill fills unset values by defaults.
+func (config *Config) Fill() {
+	def := Default()
+	config.Modules = strings.Trim(filepath.ToSlash(strings.TrimSpace(config.Modules)), "/")
+	if config.Modules == "" {
+		config.Modules = def.Modules
+	}
+	config.Output = strings.TrimSpace(config.Output)
+	if config.Output == "" {
+		config.Output = def.Output
+	}
+	config.Package = strings.TrimSpace(config.Package)
+	if config.Package == "" {
+		config.Package = path.Base(config.Modules)
+	}
+	if config.Imports.Service == "" {
+		config.Imports.Service = def.Imports.Service
+	}
+	if config.Imports.Documents == "" {
+		config.Imports.Documents = def.Imports.Documents
+	}
+	if config.Imports.Authorizations == "" {
+		config.Imports.Authorizations = def.Imports.Authorizations
+	}
+	if config.Imports.Permissions == "" {
+		config.Imports.Permissions = def.Imports.Permissions
+	}
+	if config.Imports.Validators == "" {
+		config.Imports.Validators = def.Imports.Validators
+	}
+	if config.Imports.SQL == "" {
+		config.Imports.SQL = def.Imports.SQL
+	}
+	if config.Generators == nil {
+		config.Generators = def.Generators
+	}
+}
+
+func (config *Config) Validate() (err error) {
+	if path.IsAbs(config.Modules) || config.Modules == ".." || strings.HasPrefix(config.Modules, "../") {
+		err = errors.Warning("forg: modules must be a dir in project").WithMeta("modules", config.Modules)
+		return
+	}
+	if filepath.Ext(config.Output) != ".go" || strings.ContainsAny(config.Output, "/\\") || strings.HasSuffix(config.Output, "_test.go") {
+		err = errors.Warning("forg: output must be a go filename").WithMeta("output", config.Output)
+		return
+	}
+	if !isIdent(config.Package) {
+		err = errors.Warning("forg: package must be a go identifier").WithMeta("package", config.Package)
+		return
+	}
+	return
+}
+
+// Enabled returns whether the generator is enabled, generators are enabled by default.
+func (config *Config) Enabled(generator string) (ok bool) {
+	enabled, has := config.Generators[generator]
+	ok = !has || enabled
+	return
+}
+
+// Unknown returns generator names which are not in known.
+func (config *Config) Unknown(known ...string) (names []string) {
+	names = make([]string, 0, 1)
+	for name := range config.Generators {
+		found := false
+		for _, k := range known {
+			if name == k {
+				found = true
+				break
+			}
+		}
+		if !found {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return
+}
+
+func isIdent(s string) (ok bool) {
+	if s == "" {
+		return
+	}
+	for i, c := range s {
+		if c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (i > 0 && c >= '0' && c <= '9') {
+			continue
+		}
+		return
+	}
+	ok = true
+	return
+}
@@ -0,0 +1,51 @@
+package configs_test
+
+import (
+	"github.com/aacfactory/forg/configs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRead(t *testing.T) {
+	dir := t.TempDir()
+	content := "modules: internal/services\noutput: fns_gen.go\nimports:\n  service: example.com/fns/service\ngenerators:\n  deploys: false\n"
+	if err := os.WriteFile(filepath.Join(dir, "forg.yaml"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	filename := configs.Find(dir)
+	if filename == "" {
+		t.Fatal("config file was not found")
+	}
+	config, readErr := configs.Read(filename)
+	if readErr != nil {
+		t.Fatalf("%+v", readErr)
+	}
+	config.Fill()
+	if err := config.Validate(); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if config.Modules != "internal/services" || config.Output != "fns_gen.go" || config.Package != "services" {
+		t.Errorf("unexpected config: %+v", config)
+	}
+	if config.Imports.Service != "example.com/fns/service" || config.Imports.Documents != configs.Default().Imports.Documents {
+		t.Errorf("unexpected imports: %+v", config.Imports)
+	}
+	if config.Enabled(configs.DeploysGenerator) || !config.Enabled(configs.ServicesGenerator) {
+		t.Errorf("unexpected generators: %+v", config.Generators)
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	for _, config := range []*configs.Config{
+		{Modules: "../modules"},
+		{Output: "fns.txt"},
+		{Output: "fns_test.go"},
+		{Package: "my-modules"},
+	} {
+		config.Fill()
+		if err := config.Validate(); err == nil {
+			t.Errorf("expected invalid: %+v", config)
+		}
+	}
+}
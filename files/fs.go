@@ -0,0 +1,127 @@
+package files
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Name returns the name of absolute path in fs.FS, it is the slash path without the leading slash,
+// such as /home/user/project/go.mod is home/user/project/go.mod, and C:\project\go.mod is C:/project/go.mod.
+// A relative path is joined to the working dir.
+func Name(path string) (name string) {
+	if !filepath.IsAbs(path) {
+		if absolute, absErr := filepath.Abs(path); absErr == nil {
+			path = absolute
+		}
+	}
+	name = strings.TrimPrefix(filepath.ToSlash(filepath.Clean(path)), "/")
+	if name == "" {
+		name = "."
+	}
+	return
+}
+
+// Disk returns the file system of disk, names of it are made by Name.
+func Disk() (fsys fs.FS) {
+	fsys = disk{}
+	return
+}
+
+type disk struct{}
+
+func (disk) path(name string) (path string) {
+	path = filepath.FromSlash(name)
+	if !filepath.IsAbs(path) {
+		path = string(filepath.Separator) + path
+	}
+	return
+}
+
+func (d disk) Open(name string) (fs.File, error) {
+	return os.Open(d.path(name))
+}
+
+func (d disk) Stat(name string) (fs.FileInfo, error) {
+	return os.Stat(d.path(name))
+}
+
+func (d disk) ReadFile(name string) ([]byte, error) {
+	return os.ReadFile(d.path(name))
+}
+
+func (d disk) ReadDir(name string) ([]fs.DirEntry, error) {
+	return os.ReadDir(d.path(name))
+}
+
+// Exist reports whether the absolute path exists in the file system.
+func Exist(fsys fs.FS, path string) (ok bool) {
+	_, err := fs.Stat(fsys, Name(path))
+	ok = err == nil
+	return
+}
+
+// ReadFile reads the file of absolute path in the file system.
+func ReadFile(fsys fs.FS, path string) (content []byte, err error) {
+	content, err = fs.ReadFile(fsys, Name(path))
+	return
+}
+
+// ReadDir reads the dir of absolute path in the file system, entries are sorted by name.
+func ReadDir(fsys fs.FS, path string) (entries []fs.DirEntry, err error) {
+	entries, err = fs.ReadDir(fsys, Name(path))
+	return
+}
+
+// Writer writes generated files, the disk is used by default, and an editor or a remote code host can implement it.
+type Writer interface {
+	// WriteFile writes the content into the file of absolute path, parent dirs are created when they are not existed.
+	WriteFile(filename string, content []byte) (err error)
+	// Remove removes the file of absolute path.
+	Remove(filename string) (err error)
+}
+
+// DiskWriter returns the writer of disk.
+func DiskWriter() (writer Writer) {
+	writer = diskWriter{}
+	return
+}
+
+type diskWriter struct{}
+
+func (diskWriter) WriteFile(filename string, content []byte) (err error) {
+	err = os.MkdirAll(filepath.Dir(filename), 0755)
+	if err != nil {
+		return
+	}
+	writer, openErr := os.OpenFile(filename, os.O_CREATE|os.O_TRUNC|os.O_RDWR|os.O_SYNC, 0644)
+	if openErr != nil {
+		err = openErr
+		return
+	}
+	n := 0
+	contentLen := len(content)
+	for n < contentLen {
+		nn, writeErr := writer.Write(content[n:])
+		if writeErr != nil {
+			_ = writer.Close()
+			err = writeErr
+			return
+		}
+		n += nn
+	}
+	syncErr := writer.Sync()
+	if syncErr != nil {
+		_ = writer.Close()
+		err = syncErr
+		return
+	}
+	err = writer.Close()
+	return
+}
+
+func (diskWriter) Remove(filename string) (err error) {
+	err = os.Remove(filename)
+	return
+}
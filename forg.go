@@ -2,20 +2,43 @@ package forg
 
 import (
 	"context"
+	"fmt"
 	"github.com/aacfactory/errors"
 	"github.com/aacfactory/forg/codes"
+	"github.com/aacfactory/forg/configs"
+	"github.com/aacfactory/forg/files"
 	"github.com/aacfactory/forg/module"
 	"github.com/aacfactory/forg/processes"
+	"io/fs"
 	"path/filepath"
 	"strings"
 )
 
 type Options struct {
-	Workspace string
+	Workspace  string
+	DryRun     bool
+	Packages   bool
+	GOOS       string
+	GOARCH     string
+	Tags       []string
+	Cache      string
+	FS         fs.FS
+	Writer     files.Writer
+	Parallel   int
+	Retry      *processes.RetryPolicy
+	Events     bool
+	ConfigFile string
+	Modules    string
+	Output     string
+	Package    string
+	Enables    map[string]bool
+	Generators []Generator
 }
 
 type Option func(options *Options) (err error)
 
+// WithWorkspace uses the go.work file, off disables the workspace.
+// Without it, the workspace is found in the way of go command, see module.FindWork.
 func WithWorkspace(workspace string) Option {
 	return func(options *Options) (err error) {
 		workspace = strings.TrimSpace(workspace)
@@ -28,9 +51,212 @@ func WithWorkspace(workspace string) Option {
 	}
 }
 
+func WithDryRun() Option {
+	return func(options *Options) (err error) {
+		options.DryRun = true
+		return
+	}
+}
+
+// WithPackagesLoader builds types of functions from go/types information which is loaded by go command,
+// it resolves dot imports and type aliases, but it is slower than the default ast loader.
+func WithPackagesLoader() Option {
+	return func(options *Options) (err error) {
+		options.Packages = true
+		return
+	}
+}
+
+// WithGOOS selects source files for the os by build constraints, it is same as go env GOOS by default.
+func WithGOOS(goos string) Option {
+	return func(options *Options) (err error) {
+		goos = strings.TrimSpace(goos)
+		if goos == "" {
+			err = errors.Warning("forg: GOOS option is invalid")
+			return
+		}
+		options.GOOS = goos
+		return
+	}
+}
+
+// WithGOARCH selects source files for the architecture by build constraints, it is same as go env GOARCH by default.
+func WithGOARCH(goarch string) Option {
+	return func(options *Options) (err error) {
+		goarch = strings.TrimSpace(goarch)
+		if goarch == "" {
+			err = errors.Warning("forg: GOARCH option is invalid")
+			return
+		}
+		options.GOARCH = goarch
+		return
+	}
+}
+
+// WithTags selects source files by build tags, it is same as -tags of go command.
+func WithTags(tags ...string) Option {
+	return func(options *Options) (err error) {
+		options.Tags = append(options.Tags, tags...)
+		return
+	}
+}
+
+// WithCache stores parsed services and types of functions in the dir, see module.CacheDir for the default dir.
+func WithCache(dir string) Option {
+	return func(options *Options) (err error) {
+		dir = strings.TrimSpace(dir)
+		if dir == "" {
+			err = errors.Warning("forg: cache option is invalid")
+			return
+		}
+		options.Cache = dir
+		return
+	}
+}
+
+// WithFS reads sources, go.mod, go.work and config file from the file system instead of the disk,
+// names of it are made by files.Name of absolute paths, such as an fstest.MapFS or unsaved buffers of an editor.
+func WithFS(fsys fs.FS) Option {
+	return func(options *Options) (err error) {
+		if fsys == nil {
+			err = errors.Warning("forg: fs option is invalid")
+			return
+		}
+		options.FS = fsys
+		return
+	}
+}
+
+// WithWriter writes code files and new sources by the writer instead of the disk, and removes files by it in Clean.
+func WithWriter(writer files.Writer) Option {
+	return func(options *Options) (err error) {
+		if writer == nil {
+			err = errors.Warning("forg: writer option is invalid")
+			return
+		}
+		options.Writer = writer
+		return
+	}
+}
+
+// WithMaxParallel limits the number of functions which are parsed and files which are written at the same time,
+// there is no limit by default.
+func WithMaxParallel(n int) Option {
+	return func(options *Options) (err error) {
+		if n < 1 {
+			err = errors.Warning("forg: max parallel option is invalid, it must be positive").WithMeta("parallel", fmt.Sprintf("%d", n))
+			return
+		}
+		options.Parallel = n
+		return
+	}
+}
+
+// WithRetry handles failed units of coding again by the policy, such as writing files on a slow disk.
+func WithRetry(policy processes.RetryPolicy) Option {
+	return func(options *Options) (err error) {
+		if policy.MaxAttempts < 2 {
+			err = errors.Warning("forg: retry option is invalid, max attempts must be greater than 1").WithMeta("attempts", fmt.Sprintf("%d", policy.MaxAttempts))
+			return
+		}
+		options.Retry = &policy
+		return
+	}
+}
+
+// WithEvents sends events of units, steps and the process by coding besides results of units, see processes.Event.
+func WithEvents() Option {
+	return func(options *Options) (err error) {
+		options.Events = true
+		return
+	}
+}
+
+// WithConfigFile uses the config file instead of forg.yaml, forg.yml or forg.json in project dir.
+func WithConfigFile(filename string) Option {
+	return func(options *Options) (err error) {
+		filename = strings.TrimSpace(filename)
+		if filename == "" {
+			err = errors.Warning("forg: config file option is invalid")
+			return
+		}
+		options.ConfigFile = filename
+		return
+	}
+}
+
+func WithModulesDir(dir string) Option {
+	return func(options *Options) (err error) {
+		dir = strings.TrimSpace(dir)
+		if dir == "" {
+			err = errors.Warning("forg: modules dir option is invalid")
+			return
+		}
+		options.Modules = dir
+		return
+	}
+}
+
+func WithOutputFilename(filename string) Option {
+	return func(options *Options) (err error) {
+		filename = strings.TrimSpace(filename)
+		if filename == "" {
+			err = errors.Warning("forg: output filename option is invalid")
+			return
+		}
+		options.Output = filename
+		return
+	}
+}
+
+func WithPackageName(name string) Option {
+	return func(options *Options) (err error) {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			err = errors.Warning("forg: package name option is invalid")
+			return
+		}
+		options.Package = name
+		return
+	}
+}
+
+// WithGenerator turns the generator on or off, it overrides the generators of config file.
+func WithGenerator(name string, enabled bool) Option {
+	return func(options *Options) (err error) {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			err = errors.Warning("forg: generator option is invalid")
+			return
+		}
+		if options.Enables == nil {
+			options.Enables = make(map[string]bool)
+		}
+		options.Enables[name] = enabled
+		return
+	}
+}
+
 func Load(dir string, options ...Option) (project *Project, err error) {
 	opt := &Options{
-		Workspace: "",
+		Workspace:  "",
+		DryRun:     false,
+		Packages:   false,
+		GOOS:       "",
+		GOARCH:     "",
+		Tags:       nil,
+		Cache:      "",
+		FS:         nil,
+		Writer:     nil,
+		Parallel:   0,
+		Retry:      nil,
+		Events:     false,
+		ConfigFile: "",
+		Modules:    "",
+		Output:     "",
+		Package:    "",
+		Enables:    nil,
+		Generators: nil,
 	}
 	if options != nil && len(options) > 0 {
 		for _, option := range options {
@@ -45,25 +271,138 @@ func Load(dir string, options ...Option) (project *Project, err error) {
 		err = errors.Warning("forg: load project failed").WithCause(errors.Warning("project dir is nil"))
 		return
 	}
+	fsys := opt.FS
+	if fsys == nil {
+		fsys = files.Disk()
+	}
+	writer := opt.Writer
+	if writer == nil {
+		writer = files.DiskWriter()
+	}
+	config, configErr := loadConfig(dir, fsys, opt)
+	if configErr != nil {
+		err = errors.Warning("forg: load project failed").WithCause(configErr)
+		return
+	}
+	workspace := opt.Workspace
+	if workspace == "" {
+		workspace, err = module.FindWorkFS(fsys, dir)
+		if err != nil {
+			err = errors.Warning("forg: load project failed").WithCause(err)
+			return
+		}
+	} else if workspace == module.WorkOff {
+		workspace = ""
+	}
+	moduleOptions := []module.Option{module.WithConfig(config)}
+	if opt.FS != nil {
+		moduleOptions = append(moduleOptions, module.WithFS(opt.FS))
+	}
+	if opt.Packages {
+		moduleOptions = append(moduleOptions, module.WithPackagesLoader())
+	}
+	if opt.GOOS != "" {
+		moduleOptions = append(moduleOptions, module.WithGOOS(opt.GOOS))
+	}
+	if opt.GOARCH != "" {
+		moduleOptions = append(moduleOptions, module.WithGOARCH(opt.GOARCH))
+	}
+	if len(opt.Tags) > 0 {
+		moduleOptions = append(moduleOptions, module.WithTags(opt.Tags...))
+	}
+	if opt.Cache != "" && opt.Cache != module.CacheOff {
+		moduleOptions = append(moduleOptions, module.WithCache(opt.Cache))
+	}
 	moduleFilename := filepath.Join(dir, "go.mod")
 	var mod *module.Module
-	if opt.Workspace != "" {
-		mod, err = module.NewWithWork(moduleFilename, opt.Workspace)
+	if workspace != "" {
+		mod, err = module.NewWithWork(moduleFilename, workspace, moduleOptions...)
 	} else {
-		mod, err = module.New(moduleFilename)
+		mod, err = module.New(moduleFilename, moduleOptions...)
 	}
 	if err != nil {
 		err = errors.Warning("forg: load project failed").WithCause(err)
 		return
 	}
 	project = &Project{
-		Mod: mod,
+		Mod:        mod,
+		Config:     config,
+		dryRun:     opt.DryRun,
+		generators: opt.Generators,
+		fsys:       fsys,
+		writer:     writer,
+		parallel:   opt.Parallel,
+		retry:      opt.Retry,
+		events:     opt.Events,
+	}
+	return
+}
+
+// loadConfig reads the config file of project, and then overrides it by options.
+func loadConfig(dir string, fsys fs.FS, opt *Options) (config *configs.Config, err error) {
+	filename := opt.ConfigFile
+	if filename == "" {
+		filename = configs.FindFS(fsys, dir)
+	}
+	if filename != "" {
+		config, err = configs.ReadFS(fsys, filename)
+		if err != nil {
+			return
+		}
+	} else {
+		config = &configs.Config{}
+	}
+	if opt.Modules != "" {
+		config.Modules = opt.Modules
+	}
+	if opt.Output != "" {
+		config.Output = opt.Output
+	}
+	if opt.Package != "" {
+		config.Package = opt.Package
+	}
+	if len(opt.Enables) > 0 {
+		if config.Generators == nil {
+			config.Generators = make(map[string]bool)
+		}
+		for name, enabled := range opt.Enables {
+			config.Generators[name] = enabled
+		}
+	}
+	config.Fill()
+	err = config.Validate()
+	if err != nil {
+		return
+	}
+	known := []string{configs.ServicesGenerator, configs.DeploysGenerator}
+	for _, generator := range opt.Generators {
+		known = append(known, generator.Name())
+	}
+	if unknown := config.Unknown(known...); len(unknown) > 0 {
+		err = errors.Warning("forg: unknown generators").WithMeta("generators", strings.Join(unknown, ", "))
+		return
 	}
 	return
 }
 
 type Project struct {
-	Mod *module.Module
+	Mod        *module.Module
+	Config     *configs.Config
+	dryRun     bool
+	generators []Generator
+	fsys       fs.FS
+	writer     files.Writer
+	parallel   int
+	retry      *processes.RetryPolicy
+	events     bool
+}
+
+// Workspace returns the go.work file which is used by the project, it is empty when no workspace is used.
+func (project *Project) Workspace() (filename string) {
+	if project.Mod.Work != nil {
+		filename = project.Mod.Work.Filename
+	}
+	return
 }
 
 func (project *Project) Coding(ctx context.Context) (controller processes.ProcessController, err error) {
@@ -77,18 +416,117 @@ func (project *Project) Coding(ctx context.Context) (controller processes.Proces
 		err = errors.Warning("forg: project coding failed").WithCause(servicesErr)
 		return
 	}
-	process := processes.New()
-	functionParseUnits := make([]processes.Unit, 0, 1)
-	serviceCodeFileUnits := make([]processes.Unit, 0, 1)
+	controller = project.coding(services, services, true)
+	return
+}
+
+// coding makes the process which parses functions and writes code files of affected services,
+// and writes deploys file of all services when deploys is true.
+func (project *Project) coding(services module.Services, affected module.Services, deploys bool) (process *processes.Process) {
+	options := []processes.Option{processes.WithMaxParallel(project.parallel)}
+	if project.retry != nil {
+		options = append(options, processes.WithRetry(*project.retry))
+	}
+	if project.events {
+		options = append(options, processes.WithEvents())
+	}
+	process = processes.New(options...)
+	if project.Config.Enabled(configs.ServicesGenerator) {
+		// code file of a service is written as soon as functions of the service are parsed
+		units := make([]processes.Unit, 0, 1)
+		for _, service := range affected {
+			functionParseUnits := make([]processes.Unit, 0, len(service.Functions))
+			for _, function := range service.Functions {
+				functionParseUnits = append(functionParseUnits, function)
+			}
+			units = append(units, functionParseUnits...)
+			units = append(units, processes.Depend(project.codeFileUnit(codes.NewServiceFile(service, project.Config)), functionParseUnits...))
+		}
+		process.Add("services: coding", units...)
+	} else if project.parsingRequired() {
+		functionParseUnits := make([]processes.Unit, 0, 1)
+		for _, service := range affected {
+			for _, function := range service.Functions {
+				functionParseUnits = append(functionParseUnits, function)
+			}
+		}
+		process.Add("services: parsing", functionParseUnits...)
+	}
+	if deploys && project.Config.Enabled(configs.DeploysGenerator) {
+		process.Add("services: deploys", project.codeFileUnit(project.deploysFile(services)))
+	}
+	project.addGeneratorSteps(process, services)
+	return
+}
+
+// parsingRequired returns whether functions must be parsed, service files and registered generators use types of functions.
+func (project *Project) parsingRequired() (ok bool) {
+	if project.Config.Enabled(configs.ServicesGenerator) {
+		ok = true
+		return
+	}
+	for _, generator := range project.generators {
+		if project.Config.Enabled(generator.Name()) {
+			ok = true
+			return
+		}
+	}
+	return
+}
+
+func (project *Project) deploysFile(services module.Services) (file codes.CodeFile) {
+	file = codes.NewDeploysFile(filepath.ToSlash(filepath.Join(project.Mod.Dir, project.Config.Modules)), services, project.Config)
+	return
+}
+
+func (project *Project) codeFileUnit(file codes.CodeFile) (unit processes.Unit) {
+	if project.dryRun {
+		unit = codes.DryRunUnitFS(file, project.Mod.Dir, project.fsys)
+		return
+	}
+	unit = codes.WriterUnit(file, project.writer)
+	return
+}
+
+func (project *Project) Clean(ctx context.Context) (removed []string, err error) {
+	parseErr := project.Mod.Parse(ctx)
+	if parseErr != nil {
+		err = errors.Warning("forg: project clean failed").WithCause(parseErr)
+		return
+	}
+	services, servicesErr := project.Mod.Services()
+	if servicesErr != nil {
+		err = errors.Warning("forg: project clean failed").WithCause(servicesErr)
+		return
+	}
+	filenames := make([]string, 0, len(services)+1)
 	for _, service := range services {
-		for _, function := range service.Functions {
-			functionParseUnits = append(functionParseUnits, function)
+		filenames = append(filenames, codes.NewServiceFile(service, project.Config).Name())
+	}
+	filenames = append(filenames, project.deploysFile(services).Name())
+	removed = make([]string, 0, len(filenames))
+	for _, filename := range filenames {
+		if ctx.Err() != nil {
+			err = errors.Warning("forg: project clean failed").WithCause(ctx.Err())
+			return
+		}
+		if !files.Exist(project.fsys, filename) {
+			continue
+		}
+		content, readErr := files.ReadFile(project.fsys, filename)
+		if readErr != nil {
+			err = errors.Warning("forg: project clean failed").WithCause(readErr).WithMeta("file", filename)
+			return
+		}
+		if !codes.Generated(content) {
+			continue
+		}
+		removeErr := project.writer.Remove(filename)
+		if removeErr != nil {
+			err = errors.Warning("forg: project clean failed").WithCause(removeErr).WithMeta("file", filename)
+			return
 		}
-		serviceCodeFileUnits = append(serviceCodeFileUnits, codes.Unit(codes.NewServiceFile(service)))
+		removed = append(removed, filename)
 	}
-	process.Add("services: parsing", functionParseUnits...)
-	process.Add("services: writing", serviceCodeFileUnits...)
-	process.Add("services: deploys", codes.Unit(codes.NewDeploysFile(filepath.ToSlash(filepath.Join(project.Mod.Dir, "modules")), services)))
-	controller = process
 	return
 }
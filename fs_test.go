@@ -0,0 +1,183 @@
+package forg_test
+
+import (
+	"context"
+	"errors"
+	"github.com/aacfactory/forg"
+	"github.com/aacfactory/forg/files"
+	"github.com/aacfactory/forg/processes"
+	"github.com/aacfactory/forg/scaffolds"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// overlay reads files of buffers first, and then the disk, like an editor with unsaved buffers.
+type overlay struct {
+	locker  sync.Mutex
+	buffers fstest.MapFS
+}
+
+func (o *overlay) Open(name string) (fs.File, error) {
+	o.locker.Lock()
+	defer o.locker.Unlock()
+	file, err := o.buffers.Open(name)
+	if err == nil {
+		return file, nil
+	}
+	return files.Disk().Open(name)
+}
+
+func (o *overlay) WriteFile(filename string, content []byte) (err error) {
+	o.locker.Lock()
+	defer o.locker.Unlock()
+	o.buffers[files.Name(filename)] = &fstest.MapFile{Data: content, Mode: 0644}
+	return
+}
+
+func (o *overlay) Remove(filename string) (err error) {
+	o.locker.Lock()
+	defer o.locker.Unlock()
+	name := files.Name(filename)
+	if _, has := o.buffers[name]; !has {
+		err = fs.ErrNotExist
+		return
+	}
+	delete(o.buffers, name)
+	return
+}
+
+func TestLoad_FS(t *testing.T) {
+	// the project is not on the disk, required modules are read from the disk.
+	dir := filepath.Join(t.TempDir(), "virtual")
+	o := &overlay{
+		buffers: fstest.MapFS{},
+	}
+	src := filepath.Join("testdata", "standalone")
+	err := filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		rel, relErr := filepath.Rel(src, path)
+		if relErr != nil {
+			return relErr
+		}
+		p, readErr := os.ReadFile(path)
+		if readErr != nil {
+			return readErr
+		}
+		return o.WriteFile(filepath.Join(dir, rel), p)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, loadErr := forg.Load(dir, forg.WithFS(o), forg.WithWriter(o))
+	if loadErr != nil {
+		t.Fatalf("%+v", loadErr)
+	}
+	model, inspectErr := p.Inspect(context.TODO())
+	if inspectErr != nil {
+		t.Fatalf("%+v", inspectErr)
+	}
+	if len(model.Services) != 1 || len(model.Services[0].Functions) != 1 {
+		t.Fatalf("unexpected model: %+v", model.Services)
+	}
+	process, codingErr := p.Coding(context.TODO())
+	if codingErr != nil {
+		t.Fatalf("%+v", codingErr)
+	}
+	for result := range process.Start(context.TODO()) {
+		if result.Error != nil {
+			t.Fatalf("%+v", result.Error)
+		}
+	}
+	generated := filepath.Join(dir, "modules", "users", "fns.go")
+	if !files.Exist(o, generated) {
+		t.Fatal("expect code file is written by the writer")
+	}
+	if _, statErr := os.Stat(dir); !errors.Is(statErr, fs.ErrNotExist) {
+		t.Fatalf("expect nothing is written to the disk, got %v", statErr)
+	}
+	stales, checkErr := p.Check(context.TODO())
+	if checkErr != nil {
+		t.Fatalf("%+v", checkErr)
+	}
+	if len(stales) != 0 {
+		t.Fatalf("expect no stale files, got %d", len(stales))
+	}
+	removed, cleanErr := p.Clean(context.TODO())
+	if cleanErr != nil {
+		t.Fatalf("%+v", cleanErr)
+	}
+	if len(removed) != 2 || files.Exist(o, generated) {
+		t.Fatalf("expect code files are removed by the writer, got %v", removed)
+	}
+}
+
+func TestInit_FS(t *testing.T) {
+	// the project is initialized and watched in the buffers, nothing is on the disk.
+	dir := filepath.Join(t.TempDir(), "virtual")
+	o := &overlay{
+		buffers: fstest.MapFS{},
+	}
+	project, _ := scaffolds.NewProject("example.com/hello")
+	created, initErr := forg.Init(context.TODO(), dir, project, forg.WithFS(o), forg.WithWriter(o))
+	if initErr != nil {
+		t.Fatalf("%+v", initErr)
+	}
+	for _, filename := range created {
+		if !files.Exist(o, filename) {
+			t.Fatalf("expect %s is written by the writer", filename)
+		}
+	}
+	if _, statErr := os.Stat(dir); !errors.Is(statErr, fs.ErrNotExist) {
+		t.Fatalf("expect nothing is written to the disk, got %v", statErr)
+	}
+	if _, initErr = forg.Init(context.TODO(), dir, project, forg.WithFS(o), forg.WithWriter(o)); initErr == nil {
+		t.Fatal("existing files in the file system must not be overwritten")
+	}
+	p, loadErr := forg.Load(dir, forg.WithFS(o), forg.WithWriter(o))
+	if loadErr != nil {
+		t.Fatalf("%+v", loadErr)
+	}
+	ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Second)
+	defer cancel()
+	rounds := make(chan []string, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- p.Watch(ctx, 20*time.Millisecond, func(changed []string, results <-chan processes.Result) {
+			for result := range results {
+				if result.Error != nil {
+					t.Errorf("%+v", result.Error)
+				}
+			}
+			select {
+			case rounds <- changed:
+				break
+			default:
+				break
+			}
+		})
+	}()
+	select {
+	case changed := <-rounds:
+		if strings.Join(changed, ",") != "users" {
+			t.Errorf("unexpected first round: %v", changed)
+		}
+		break
+	case err := <-done:
+		t.Fatalf("%+v", err)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if !files.Exist(o, filepath.Join(dir, "modules", "users", "fns.go")) {
+		t.Error("expect code file is written by the writer")
+	}
+}
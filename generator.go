@@ -0,0 +1,86 @@
+package forg
+
+import (
+	"context"
+	"github.com/aacfactory/errors"
+	"github.com/aacfactory/forg/codes"
+	"github.com/aacfactory/forg/configs"
+	"github.com/aacfactory/forg/module"
+	"github.com/aacfactory/forg/processes"
+	"strings"
+)
+
+// Generator makes extra outputs of a project, such as documents, clients or mocks.
+// Generate is called after functions of services are parsed, so types of params and results are ready.
+// Files are written (or diffed in dry-run mode) and units are handled in a step named by the generator.
+// Generate must not write anything, because Check uses files without handling units, so side effects belong to units.
+// A generator can be turned on or off by its name in the generators of config.
+type Generator interface {
+	Name() (name string)
+	Generate(ctx context.Context, mod *module.Module, services module.Services) (files []codes.CodeFile, units []processes.Unit, err error)
+}
+
+// WithGenerators registers generators which run after built-in generators in order.
+func WithGenerators(generators ...Generator) Option {
+	return func(options *Options) (err error) {
+		for _, generator := range generators {
+			if generator == nil {
+				err = errors.Warning("forg: generators option is invalid").WithCause(errors.Warning("generator is nil"))
+				return
+			}
+			name := strings.TrimSpace(generator.Name())
+			if name == "" {
+				err = errors.Warning("forg: generators option is invalid").WithCause(errors.Warning("generator name is blank"))
+				return
+			}
+			if name == configs.ServicesGenerator || name == configs.DeploysGenerator {
+				err = errors.Warning("forg: generators option is invalid").WithCause(errors.Warning("generator name is reserved")).WithMeta("generator", name)
+				return
+			}
+			for _, registered := range options.Generators {
+				if registered.Name() == name {
+					err = errors.Warning("forg: generators option is invalid").WithCause(errors.Warning("generator was duplicated")).WithMeta("generator", name)
+					return
+				}
+			}
+			options.Generators = append(options.Generators, generator)
+		}
+		return
+	}
+}
+
+// generate calls the generator, it requires functions of services are parsed.
+func (project *Project) generate(ctx context.Context, generator Generator, services module.Services) (files []codes.CodeFile, units []processes.Unit, err error) {
+	if ctx.Err() != nil {
+		err = errors.Warning("forg: generate failed").WithMeta("generator", generator.Name()).WithCause(ctx.Err())
+		return
+	}
+	files, units, err = generator.Generate(ctx, project.Mod, services)
+	if err != nil {
+		err = errors.Warning("forg: generate failed").WithMeta("generator", generator.Name()).WithCause(err)
+		return
+	}
+	return
+}
+
+func (project *Project) addGeneratorSteps(process *processes.Process, services module.Services) {
+	for _, generator := range project.generators {
+		if !project.Config.Enabled(generator.Name()) {
+			continue
+		}
+		generator := generator
+		process.AddLazy(generator.Name()+": generating", func(ctx context.Context) (units []processes.Unit, err error) {
+			files, generated, generateErr := project.generate(ctx, generator, services)
+			if generateErr != nil {
+				err = generateErr
+				return
+			}
+			units = make([]processes.Unit, 0, len(files)+len(generated))
+			for _, file := range files {
+				units = append(units, project.codeFileUnit(file))
+			}
+			units = append(units, generated...)
+			return
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package forg_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/aacfactory/forg"
+	"github.com/aacfactory/forg/codes"
+	"github.com/aacfactory/forg/module"
+	"github.com/aacfactory/forg/processes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type fnListFile struct {
+	filename string
+	services module.Services
+}
+
+func (file *fnListFile) Name() (name string) {
+	name = file.filename
+	return
+}
+
+func (file *fnListFile) Render(ctx context.Context) (content []byte, err error) {
+	lines := make([]string, 0, 1)
+	for _, service := range file.services {
+		for _, function := range service.Functions {
+			lines = append(lines, fmt.Sprintf("%s/%s %s", service.Name, function.Name(), function.Result.Type.Name))
+		}
+	}
+	content = []byte(strings.Join(lines, "\n") + "\n")
+	return
+}
+
+func (file *fnListFile) Write(ctx context.Context) (err error) {
+	content, _ := file.Render(ctx)
+	err = os.WriteFile(file.filename, content, 0644)
+	return
+}
+
+type fnListUnit struct {
+	handled *bool
+}
+
+func (unit *fnListUnit) Handle(ctx context.Context) (result interface{}, err error) {
+	*unit.handled = true
+	result = "handled"
+	return
+}
+
+type fnListGenerator struct {
+	handled bool
+}
+
+func (generator *fnListGenerator) Name() (name string) {
+	name = "fns-list"
+	return
+}
+
+func (generator *fnListGenerator) Generate(ctx context.Context, mod *module.Module, services module.Services) (files []codes.CodeFile, units []processes.Unit, err error) {
+	files = []codes.CodeFile{&fnListFile{
+		filename: filepath.Join(mod.Dir, "fns.txt"),
+		services: services,
+	}}
+	units = []processes.Unit{&fnListUnit{handled: &generator.handled}}
+	return
+}
+
+func TestProject_Generators(t *testing.T) {
+	dir := copyProject(t, filepath.Join("testdata", "standalone"))
+	generator := &fnListGenerator{}
+	p, loadErr := forg.Load(dir, forg.WithGenerators(generator), forg.WithGenerator("deploys", false))
+	if loadErr != nil {
+		t.Fatalf("%+v", loadErr)
+	}
+	process, codingErr := p.Coding(context.TODO())
+	if codingErr != nil {
+		t.Fatalf("%+v", codingErr)
+	}
+	steps := make(map[string]int)
+	for result := range process.Start(context.TODO()) {
+		if result.Error != nil {
+			t.Fatalf("%+v", result.Error)
+		}
+		steps[result.StepName]++
+	}
+	if steps["fns-list: generating"] != 2 {
+		t.Errorf("unexpected steps: %v", steps)
+	}
+	if !generator.handled {
+		t.Error("unit of generator was not handled")
+	}
+	content, readErr := os.ReadFile(filepath.Join(dir, "fns.txt"))
+	if readErr != nil {
+		t.Fatal(readErr)
+	}
+	if string(content) != "users/get User\n" {
+		t.Errorf("unexpected content: %q", content)
+	}
+	if _, loadErr = forg.Load(dir, forg.WithGenerators(generator, generator)); loadErr == nil {
+		t.Error("duplicated generators must be rejected")
+	}
+}
@@ -6,8 +6,10 @@ require (
 	github.com/aacfactory/cases v1.1.0
 	github.com/aacfactory/errors v1.13.4
 	github.com/aacfactory/gcg v1.0.4
-	golang.org/x/mod v0.8.0
-	golang.org/x/sync v0.1.0
+	golang.org/x/mod v0.20.0
+	golang.org/x/sync v0.8.0
+	golang.org/x/tools v0.24.1
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -16,6 +18,4 @@ require (
 	github.com/tidwall/match v1.1.1 // indirect
 	github.com/tidwall/pretty v1.2.1 // indirect
 	github.com/valyala/bytebufferpool v1.0.0 // indirect
-	golang.org/x/sys v0.0.0-20220722155257-8c9f86f7a55f // indirect
-	golang.org/x/tools v0.1.12 // indirect
 )
@@ -0,0 +1,198 @@
+package forg
+
+import (
+	"context"
+	"github.com/aacfactory/errors"
+	"github.com/aacfactory/forg/module"
+)
+
+// ProjectModel is the parsed model of a project, it is stable for json encoding.
+type ProjectModel struct {
+	Path      string          `json:"path"`
+	Dir       string          `json:"dir"`
+	Workspace string          `json:"workspace,omitempty"`
+	Services  []*ServiceModel `json:"services"`
+}
+
+type ServiceModel struct {
+	Name        string           `json:"name"`
+	Path        string           `json:"path"`
+	Internal    bool             `json:"internal"`
+	Title       string           `json:"title"`
+	Description string           `json:"description"`
+	Components  []string         `json:"components"`
+	Functions   []*FunctionModel `json:"functions"`
+}
+
+type FunctionModel struct {
+	Name        string            `json:"name"`
+	Ident       string            `json:"ident"`
+	File        string            `json:"file"`
+	Internal    bool              `json:"internal"`
+	Title       string            `json:"title"`
+	Description string            `json:"description"`
+	Deprecated  bool              `json:"deprecated"`
+	Timeout     string            `json:"timeout,omitempty"`
+	Annotations map[string]string `json:"annotations"`
+	Errors      []*ErrorModel     `json:"errors"`
+	Param       *FieldModel       `json:"param,omitempty"`
+	Result      *FieldModel       `json:"result,omitempty"`
+}
+
+type ErrorModel struct {
+	Name         string            `json:"name"`
+	Descriptions map[string]string `json:"descriptions"`
+}
+
+type FieldModel struct {
+	Name string     `json:"name"`
+	Type *TypeModel `json:"type"`
+}
+
+// TypeModel is the tree of module.Type, a recursive type is cut by a reference kind type.
+type TypeModel struct {
+	Kind        string            `json:"kind"`
+	Path        string            `json:"path,omitempty"`
+	Name        string            `json:"name,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+	Paradigms   []*ParadigmModel  `json:"paradigms,omitempty"`
+	Elements    []*TypeModel      `json:"elements,omitempty"`
+}
+
+type ParadigmModel struct {
+	Name  string       `json:"name"`
+	Types []*TypeModel `json:"types"`
+}
+
+// Inspect parses the project and returns its model without generating code files.
+func (project *Project) Inspect(ctx context.Context) (model *ProjectModel, err error) {
+	parseErr := project.Mod.Parse(ctx)
+	if parseErr != nil {
+		err = errors.Warning("forg: project inspect failed").WithCause(parseErr)
+		return
+	}
+	services, servicesErr := project.Mod.Services()
+	if servicesErr != nil {
+		err = errors.Warning("forg: project inspect failed").WithCause(servicesErr)
+		return
+	}
+	model = &ProjectModel{
+		Path:      project.Mod.Path,
+		Dir:       project.Mod.Dir,
+		Workspace: project.Workspace(),
+		Services:  make([]*ServiceModel, 0, len(services)),
+	}
+	for _, service := range services {
+		sm := &ServiceModel{
+			Name:        service.Name,
+			Path:        service.Path,
+			Internal:    service.Internal,
+			Title:       service.Title,
+			Description: service.Description,
+			Components:  make([]string, 0, len(service.Components)),
+			Functions:   make([]*FunctionModel, 0, len(service.Functions)),
+		}
+		for _, component := range service.Components {
+			sm.Components = append(sm.Components, component.Indent)
+		}
+		for _, function := range service.Functions {
+			fm, fnErr := inspectFunction(ctx, function)
+			if fnErr != nil {
+				err = errors.Warning("forg: project inspect failed").WithCause(fnErr)
+				model = nil
+				return
+			}
+			sm.Functions = append(sm.Functions, fm)
+		}
+		model.Services = append(model.Services, sm)
+	}
+	return
+}
+
+func inspectFunction(ctx context.Context, function *module.Function) (model *FunctionModel, err error) {
+	err = function.Parse(ctx)
+	if err != nil {
+		return
+	}
+	model = &FunctionModel{
+		Name:        function.Name(),
+		Ident:       function.Ident,
+		File:        function.Filename(),
+		Internal:    function.Internal(),
+		Title:       function.Title(),
+		Description: function.Description(),
+		Deprecated:  function.Deprecated(),
+		Timeout:     "",
+		Annotations: function.Annotations,
+		Errors:      make([]*ErrorModel, 0, 1),
+		Param:       inspectField(function.Param),
+		Result:      inspectField(function.Result),
+	}
+	if model.Annotations == nil {
+		model.Annotations = make(map[string]string)
+	}
+	timeout, hasTimeout, timeoutErr := function.Timeout()
+	if timeoutErr != nil {
+		err = errors.Warning("forg: parse function timeout failed").WithCause(timeoutErr).
+			WithMeta("service", function.HostServiceName()).WithMeta("function", function.Ident)
+		model = nil
+		return
+	}
+	if hasTimeout {
+		model.Timeout = timeout.String()
+	}
+	for _, fe := range function.Errors() {
+		model.Errors = append(model.Errors, &ErrorModel{
+			Name:         fe.Name,
+			Descriptions: fe.Descriptions,
+		})
+	}
+	return
+}
+
+func inspectField(field *module.FunctionField) (model *FieldModel) {
+	if field == nil {
+		return
+	}
+	model = &FieldModel{
+		Name: field.Name,
+		Type: inspectType(field.Type),
+	}
+	return
+}
+
+func inspectType(typ *module.Type) (model *TypeModel) {
+	if typ == nil {
+		return
+	}
+	model = &TypeModel{
+		Kind:        typ.Kind.String(),
+		Path:        typ.Path,
+		Name:        typ.Name,
+		Annotations: typ.Annotations,
+		Tags:        typ.Tags,
+		Paradigms:   nil,
+		Elements:    nil,
+	}
+	if len(typ.Paradigms) > 0 {
+		model.Paradigms = make([]*ParadigmModel, 0, len(typ.Paradigms))
+		for _, paradigm := range typ.Paradigms {
+			pm := &ParadigmModel{
+				Name:  paradigm.Name,
+				Types: make([]*TypeModel, 0, len(paradigm.Types)),
+			}
+			for _, pt := range paradigm.Types {
+				pm.Types = append(pm.Types, inspectType(pt))
+			}
+			model.Paradigms = append(model.Paradigms, pm)
+		}
+	}
+	if len(typ.Elements) > 0 {
+		model.Elements = make([]*TypeModel, 0, len(typ.Elements))
+		for _, element := range typ.Elements {
+			model.Elements = append(model.Elements, inspectType(element))
+		}
+	}
+	return
+}
@@ -0,0 +1,39 @@
+package forg_test
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/aacfactory/forg"
+	"path/filepath"
+	"testing"
+)
+
+func TestProject_Inspect(t *testing.T) {
+	p, loadErr := forg.Load(copyProject(t, filepath.Join("testdata", "standalone")))
+	if loadErr != nil {
+		t.Fatalf("%+v", loadErr)
+	}
+	model, inspectErr := p.Inspect(context.TODO())
+	if inspectErr != nil {
+		t.Fatalf("%+v", inspectErr)
+	}
+	if len(model.Services) != 1 || model.Services[0].Name != "users" || len(model.Services[0].Functions) != 1 {
+		t.Fatalf("unexpected model: %+v", model)
+	}
+	if components := model.Services[0].Components; len(components) != 1 || components[0] != "Store" {
+		t.Errorf("unexpected components: %v", components)
+	}
+	fn := model.Services[0].Functions[0]
+	if fn.Name != "get" || fn.Timeout != "1s" || len(fn.Errors) != 1 || fn.Errors[0].Name != "users_get_failed" {
+		t.Errorf("unexpected function: %+v", fn)
+	}
+	if fn.Param == nil || fn.Param.Type.Kind != "struct" || fn.Param.Type.Name != "GetParam" || len(fn.Param.Type.Elements) != 1 {
+		t.Errorf("unexpected param: %+v", fn.Param)
+	}
+	if fn.Result == nil || fn.Result.Type.Name != "User" || len(fn.Result.Type.Elements) != 3 {
+		t.Errorf("unexpected result: %+v", fn.Result)
+	}
+	if _, encodeErr := json.Marshal(model); encodeErr != nil {
+		t.Error(encodeErr)
+	}
+}
@@ -0,0 +1,22 @@
+package forg
+
+import (
+	"context"
+	"github.com/aacfactory/errors"
+	"github.com/aacfactory/forg/module"
+)
+
+// Lint parses the project and collects all violations of services and functions, issues are sorted by position.
+func (project *Project) Lint(ctx context.Context) (issues module.Issues, err error) {
+	parseErr := project.Mod.Parse(ctx)
+	if parseErr != nil {
+		err = errors.Warning("forg: project lint failed").WithCause(parseErr)
+		return
+	}
+	issues, err = project.Mod.Lint(ctx)
+	if err != nil {
+		err = errors.Warning("forg: project lint failed").WithCause(err)
+		return
+	}
+	return
+}
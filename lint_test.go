@@ -0,0 +1,89 @@
+package forg_test
+
+import (
+	"context"
+	"github.com/aacfactory/forg"
+	"github.com/aacfactory/forg/module"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProject_Lint(t *testing.T) {
+	dir := copyProject(t, filepath.Join("testdata", "standalone"))
+	p, loadErr := forg.Load(dir)
+	if loadErr != nil {
+		t.Fatalf("%+v", loadErr)
+	}
+	issues, lintErr := p.Lint(context.TODO())
+	if lintErr != nil {
+		t.Fatalf("%+v", lintErr)
+	}
+	if len(issues) != 0 {
+		t.Fatalf("unexpected issues: %v", issues)
+	}
+	bad := `package users
+
+import (
+	"context"
+	"github.com/aacfactory/errors"
+)
+
+// Find
+// @fn find
+func Find(ctx context.Context, param map[string]string) (result User, err errors.CodeError) {
+	return
+}
+
+// list
+// @fn get
+// @title List users
+// @timeout one
+func list(param GetParam) (result User, err error) {
+	return
+}
+`
+	if writeErr := os.WriteFile(filepath.Join(dir, "modules", "users", "bad.go"), []byte(bad), 0644); writeErr != nil {
+		t.Fatal(writeErr)
+	}
+	issues, lintErr = p.Lint(context.TODO())
+	if lintErr != nil {
+		t.Fatalf("%+v", lintErr)
+	}
+	for _, issue := range issues {
+		t.Log(issue)
+		if issue.Line == 0 || issue.Column == 0 {
+			t.Errorf("unexpected position: %s", issue)
+		}
+	}
+	expects := []struct {
+		file    string
+		level   string
+		line    int
+		message string
+	}{
+		{file: "bad.go", level: module.LintWarning, line: 8, message: "no @title"},
+		{file: "bad.go", level: module.LintError, line: 10, message: "must not be exported"},
+		{file: "bad.go", level: module.LintError, line: 10, message: "invalid param type"},
+		{file: "get.go", level: module.LintError, line: 24, message: "fn get was declared"},
+		{file: "bad.go", level: module.LintError, line: 14, message: "@timeout one"},
+		{file: "bad.go", level: module.LintError, line: 18, message: "first param must be context.Context"},
+		{file: "bad.go", level: module.LintError, line: 18, message: "last result must be"},
+	}
+	for _, expect := range expects {
+		found := false
+		for _, issue := range issues {
+			if strings.HasSuffix(issue.File, "modules/users/"+expect.file) && issue.Level == expect.level && issue.Line == expect.line && strings.Contains(issue.Message, expect.message) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("issue %s at %s:%d %q was not reported", expect.level, expect.file, expect.line, expect.message)
+		}
+	}
+	if issues.Errors() != len(expects)-1 {
+		t.Errorf("expect %d errors, got %d", len(expects)-1, issues.Errors())
+	}
+}
@@ -0,0 +1,58 @@
+package module
+
+import (
+	"go/build"
+	"runtime"
+	"strings"
+)
+
+// newBuildContext returns the context which selects source files by build constraints like go command,
+// GOOS and GOARCH are same as go env when they are empty, and cgo is enabled by CGO_ENABLED or by building for host.
+func newBuildContext(goos string, goarch string, tags []string) (ctx *build.Context) {
+	v := build.Default
+	ctx = &v
+	if goos == "" {
+		goos, _ = goEnv("GOOS")
+	}
+	if goos == "" {
+		goos = runtime.GOOS
+	}
+	if goarch == "" {
+		goarch, _ = goEnv("GOARCH")
+	}
+	if goarch == "" {
+		goarch = runtime.GOARCH
+	}
+	ctx.GOOS = goos
+	ctx.GOARCH = goarch
+	cgo, hasCgo := goEnv("CGO_ENABLED")
+	if hasCgo {
+		ctx.CgoEnabled = cgo == "1"
+	} else {
+		ctx.CgoEnabled = build.Default.CgoEnabled && goos == runtime.GOOS && goarch == runtime.GOARCH
+	}
+	ctx.BuildTags = make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag != "" {
+			ctx.BuildTags = append(ctx.BuildTags, tag)
+		}
+	}
+	return
+}
+
+// buildFlags returns the flags and environments of go command which use same build constraints as the context.
+func buildFlags(ctx *build.Context) (flags []string, env []string) {
+	if ctx == nil {
+		return
+	}
+	if len(ctx.BuildTags) > 0 {
+		flags = append(flags, "-tags="+strings.Join(ctx.BuildTags, ","))
+	}
+	cgo := "0"
+	if ctx.CgoEnabled {
+		cgo = "1"
+	}
+	env = []string{"GOOS=" + ctx.GOOS, "GOARCH=" + ctx.GOARCH, "CGO_ENABLED=" + cgo}
+	return
+}
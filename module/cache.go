@@ -0,0 +1,464 @@
+package module
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/aacfactory/errors"
+	"github.com/aacfactory/forg/files"
+	"go/ast"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"sync"
+)
+
+const (
+	// CacheOff disables the cache, it is same as FORGCACHE=off.
+	CacheOff = "off"
+	// cacheFormat is changed when entries of cache are changed.
+	cacheFormat       = "1"
+	cacheVersionFile  = "version"
+	forgModulePath    = "github.com/aacfactory/forg"
+	cacheServiceKind  = "service"
+	cacheFunctionKind = "function"
+)
+
+// CacheDir returns the root dir of parse cache, it is FORGCACHE, or forg in user cache dir, such as $XDG_CACHE_HOME/forg.
+// The dir is empty when FORGCACHE is off.
+func CacheDir() (dir string, err error) {
+	dir = strings.TrimSpace(os.Getenv("FORGCACHE"))
+	if dir == CacheOff {
+		dir = ""
+		return
+	}
+	if dir != "" {
+		if !filepath.IsAbs(dir) {
+			err = errors.Warning("forg: FORGCACHE is not an absolute path").WithMeta("FORGCACHE", dir)
+			return
+		}
+		dir = filepath.ToSlash(dir)
+		return
+	}
+	userCacheDir, userCacheDirErr := os.UserCacheDir()
+	if userCacheDirErr != nil {
+		err = errors.Warning("forg: get user cache dir failed").WithCause(userCacheDirErr)
+		return
+	}
+	dir = filepath.ToSlash(filepath.Join(userCacheDir, "forg"))
+	return
+}
+
+// CleanCache removes all entries of the cache in root dir, other files in root dir are kept.
+func CleanCache(root string) (err error) {
+	if root == "" {
+		return
+	}
+	err = removeCacheVersions(root, "")
+	if err != nil {
+		err = errors.Warning("forg: clean cache failed").WithCause(err).WithMeta("dir", root)
+		return
+	}
+	_ = os.Remove(root)
+	return
+}
+
+// removeCacheVersions removes dirs of cache versions in root dir except the kept one, a dir of cache version has the version file.
+func removeCacheVersions(root string, kept string) (err error) {
+	entries, readErr := os.ReadDir(root)
+	if readErr != nil {
+		if os.IsNotExist(readErr) {
+			return
+		}
+		err = readErr
+		return
+	}
+	for _, entry := range entries {
+		dir := filepath.ToSlash(filepath.Join(root, entry.Name()))
+		if !entry.IsDir() || dir == kept || !files.ExistFile(filepath.Join(dir, cacheVersionFile)) {
+			continue
+		}
+		err = os.RemoveAll(dir)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// cacheVersion returns the version of forg which writes the cache, entries of other versions are invalid.
+// A development build uses the vcs revision, or the modified time of executable when the revision is unknown.
+func cacheVersion() (version string) {
+	version = "unknown"
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return
+	}
+	if info.Main.Path == forgModulePath {
+		version = info.Main.Version
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == forgModulePath {
+			version = dep.Version
+			if dep.Replace != nil {
+				version = dep.Replace.Path + "@" + dep.Replace.Version
+			}
+		}
+	}
+	if version != "" && version != "(devel)" {
+		return
+	}
+	for _, setting := range info.Settings {
+		if setting.Key == "vcs.revision" || setting.Key == "vcs.modified" {
+			version = version + " " + setting.Value
+		}
+	}
+	if executable, executableErr := os.Executable(); executableErr == nil {
+		if stat, statErr := os.Stat(executable); statErr == nil {
+			version = version + " " + stat.ModTime().String()
+		}
+	}
+	return
+}
+
+// openCache opens the cache of current forg version in root dir, caches of other versions are removed.
+func openCache(root string) (cache *Cache, err error) {
+	version := cacheFormat + " " + cacheVersion()
+	dir := filepath.ToSlash(filepath.Join(root, hashString(version)[:16]))
+	if mkErr := os.MkdirAll(dir, 0755); mkErr != nil {
+		err = errors.Warning("forg: open cache failed").WithCause(mkErr).WithMeta("dir", dir)
+		return
+	}
+	_ = removeCacheVersions(root, dir)
+	_ = os.WriteFile(filepath.Join(dir, cacheVersionFile), []byte(version), 0644)
+	cache = &Cache{
+		dir:          dir,
+		locker:       &sync.Mutex{},
+		fingerprints: make(map[string]string),
+	}
+	return
+}
+
+// Cache stores parsed services and types of functions on disk, so unchanged packages are not parsed again.
+// An entry is keyed by file path, and it is valid only when the content hashes of its packages are not changed.
+type Cache struct {
+	dir          string
+	locker       sync.Locker
+	fingerprints map[string]string
+}
+
+func (cache *Cache) filename(key string) (filename string) {
+	filename = filepath.Join(cache.dir, key[:2], key+".json")
+	return
+}
+
+func (cache *Cache) get(key string, v any) (has bool) {
+	p, readErr := os.ReadFile(cache.filename(key))
+	if readErr != nil {
+		return
+	}
+	has = json.Unmarshal(p, v) == nil
+	return
+}
+
+// put writes the entry into a temp file and renames it, so a reader never sees a partial entry.
+func (cache *Cache) put(key string, v any) {
+	p, encodeErr := json.Marshal(v)
+	if encodeErr != nil {
+		return
+	}
+	filename := cache.filename(key)
+	if mkErr := os.MkdirAll(filepath.Dir(filename), 0755); mkErr != nil {
+		return
+	}
+	tmp, tmpErr := os.CreateTemp(filepath.Dir(filename), key+".*.tmp")
+	if tmpErr != nil {
+		return
+	}
+	_, writeErr := tmp.Write(p)
+	closeErr := tmp.Close()
+	if writeErr != nil || closeErr != nil || os.Rename(tmp.Name(), filename) != nil {
+		_ = os.Remove(tmp.Name())
+	}
+}
+
+// reset drops content hashes of packages, they are computed again when files are changed.
+func (cache *Cache) reset() {
+	cache.locker.Lock()
+	cache.fingerprints = make(map[string]string)
+	cache.locker.Unlock()
+}
+
+// fingerprint returns the hash of names and contents of source files in the package which match build constraints.
+func (cache *Cache) fingerprint(sources *Sources, path string) (hash string, err error) {
+	dir, dirErr := sources.destinationPath(path)
+	if dirErr != nil {
+		err = dirErr
+		return
+	}
+	cache.locker.Lock()
+	hash, has := cache.fingerprints[dir]
+	cache.locker.Unlock()
+	if has {
+		return
+	}
+	h := sha256.New()
+	if files.Exist(sources.fsys, dir) {
+		entries, readErr := files.ReadDir(sources.fsys, dir)
+		if readErr != nil {
+			err = errors.Warning("forg: read dir failed").WithCause(readErr).WithMeta("dir", dir)
+			return
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			matched, matchErr := sources.match(dir, entry.Name())
+			if matchErr != nil {
+				err = matchErr
+				return
+			}
+			if !matched {
+				continue
+			}
+			p, readFileErr := files.ReadFile(sources.fsys, filepath.Join(dir, entry.Name()))
+			if readFileErr != nil {
+				err = errors.Warning("forg: read file failed").WithCause(readFileErr).WithMeta("file", entry.Name()).WithMeta("dir", dir)
+				return
+			}
+			content := sha256.Sum256(p)
+			_, _ = h.Write([]byte(entry.Name()))
+			_, _ = h.Write(content[:])
+		}
+	}
+	hash = hex.EncodeToString(h.Sum(nil))
+	cache.locker.Lock()
+	cache.fingerprints[dir] = hash
+	cache.locker.Unlock()
+	return
+}
+
+func hashString(values ...string) (hash string) {
+	h := sha256.New()
+	for _, value := range values {
+		_, _ = h.Write([]byte(value))
+		_, _ = h.Write([]byte{0})
+	}
+	hash = hex.EncodeToString(h.Sum(nil))
+	return
+}
+
+// dependencyHash returns the hash of go.mod, go.sum and vendor/modules.txt of module, and go.work and go.work.sum of workspace,
+// they decide versions and replacements of requires. Files which are not existed are hashed as empty.
+func (mod *Module) dependencyHash(work *Work) (hash string) {
+	filenames := []string{
+		filepath.Join(mod.Dir, "go.mod"),
+		filepath.Join(mod.Dir, "go.sum"),
+		filepath.Join(mod.Dir, vendorDirname, vendorModulesFilename),
+	}
+	if work != nil {
+		filenames = append(filenames, work.Filename, work.Filename+".sum")
+	}
+	values := make([]string, 0, 2*len(filenames))
+	for _, filename := range filenames {
+		p, _ := files.ReadFile(mod.fsys, filename)
+		values = append(values, filepath.ToSlash(filename), string(p))
+	}
+	hash = hashString(values...)
+	return
+}
+
+// cacheKey returns the key of entry, it contains the build constraints, the loader, the config of module and the hash of dependencies,
+// because they change parsed results, such as imports of services and types of requires.
+func (mod *Module) cacheKey(kind string, values ...string) (key string) {
+	loader := "ast"
+	if mod.loadPackages {
+		loader = "packages"
+	}
+	build := ""
+	if mod.build != nil {
+		build = mod.build.GOOS + "/" + mod.build.GOARCH + "/" + strings.Join(mod.build.BuildTags, ",")
+	}
+	config, _ := json.Marshal(mod.Config())
+	mod.requiresLocker.RLock()
+	dependencies := mod.dependencies
+	mod.requiresLocker.RUnlock()
+	key = hashString(append([]string{kind, mod.Dir, loader, build, string(config), dependencies}, values...)...)
+	return
+}
+
+type serviceCacheEntry struct {
+	Has       bool              `json:"has"`
+	Service   *Service          `json:"service"`
+	Filenames map[string]string `json:"filenames"`
+}
+
+// loadService loads the service from cache when files of service and its components are not changed.
+func (mod *Module) loadService(path string) (service *Service, has bool, err error) {
+	if mod.cache == nil {
+		service, has, err = tryLoadService(mod, path)
+		return
+	}
+	serviceHash, serviceHashErr := mod.cache.fingerprint(mod.sources, path)
+	componentsHash, componentsHashErr := mod.cache.fingerprint(mod.sources, path+"/components")
+	if serviceHashErr != nil || componentsHashErr != nil {
+		service, has, err = tryLoadService(mod, path)
+		return
+	}
+	key := mod.cacheKey(cacheServiceKind, path, serviceHash, componentsHash)
+	entry := &serviceCacheEntry{}
+	if mod.cache.get(key, entry) && (!entry.Has || entry.Service != nil) {
+		has = entry.Has
+		if !has {
+			return
+		}
+		service = entry.Service
+		service.mod = mod
+		for _, function := range service.Functions {
+			function.mod = mod
+			function.hostServiceName = service.Name
+			function.path = service.Path
+			function.filename = entry.Filenames[function.Ident]
+		}
+		return
+	}
+	service, has, err = tryLoadService(mod, path)
+	if err != nil {
+		return
+	}
+	entry = &serviceCacheEntry{
+		Has:       has,
+		Service:   service,
+		Filenames: make(map[string]string),
+	}
+	if has {
+		for _, function := range service.Functions {
+			entry.Filenames[function.Ident] = function.filename
+		}
+	}
+	mod.cache.put(key, entry)
+	return
+}
+
+type functionCacheEntry struct {
+	Fingerprints map[string]string `json:"fingerprints"`
+	Param        *FunctionField    `json:"param"`
+	Result       *FunctionField    `json:"result"`
+}
+
+// loadCached uses param and result of cache when no package of their types is changed, and puts types into module.
+func (f *Function) loadCached(ctx context.Context) (ok bool) {
+	generation := f.mod.types.current()
+	entry := &functionCacheEntry{}
+	if !f.mod.cache.get(f.cacheKey(), entry) || len(entry.Fingerprints) == 0 {
+		return
+	}
+	for path, hash := range entry.Fingerprints {
+		current, currentErr := f.fingerprint(ctx, path)
+		if currentErr != nil || current != hash {
+			return
+		}
+	}
+	f.Param = entry.Param
+	f.Result = entry.Result
+	for _, field := range []*FunctionField{f.Param, f.Result} {
+		if field == nil || field.Type == nil {
+			continue
+		}
+		for key, typ := range field.Type.Flats() {
+			f.mod.types.storeIfAbsent(key, typ, generation)
+		}
+	}
+	ok = true
+	return
+}
+
+// storeCached puts param and result into cache with content hashes of packages of their types.
+func (f *Function) storeCached(ctx context.Context) {
+	paths := map[string]bool{f.path: true}
+	visited := make(map[*Type]bool)
+	for _, field := range []*FunctionField{f.Param, f.Result} {
+		if field != nil {
+			collectTypePaths(field.Type, paths, visited)
+		}
+	}
+	entry := &functionCacheEntry{
+		Fingerprints: make(map[string]string),
+		Param:        f.Param,
+		Result:       f.Result,
+	}
+	for path := range paths {
+		hash, hashErr := f.fingerprint(ctx, path)
+		if hashErr != nil {
+			return
+		}
+		if hash != "" {
+			entry.Fingerprints[path] = hash
+		}
+	}
+	f.mod.cache.put(f.cacheKey(), entry)
+}
+
+func (f *Function) cacheKey() (key string) {
+	key = f.mod.cacheKey(cacheFunctionKind, f.filename, f.Ident)
+	return
+}
+
+// fingerprint returns the content hash of the package, it is empty when the package is not in any module, such as a package of std.
+func (f *Function) fingerprint(ctx context.Context, path string) (hash string, err error) {
+	typeModule, has, findErr := f.mod.findModuleByPath(ctx, path)
+	if findErr != nil {
+		err = findErr
+		return
+	}
+	if !has {
+		return
+	}
+	hash, err = f.mod.cache.fingerprint(typeModule.sources, path)
+	return
+}
+
+// collectTypePaths collects packages of named types in the type tree.
+func collectTypePaths(typ *Type, paths map[string]bool, visited map[*Type]bool) {
+	if typ == nil || visited[typ] {
+		return
+	}
+	visited[typ] = true
+	if typ.Path != "" {
+		paths[typ.Path] = true
+	}
+	for _, element := range typ.Elements {
+		collectTypePaths(element, paths, visited)
+	}
+	for _, paradigm := range typ.Paradigms {
+		for _, pt := range paradigm.Types {
+			collectTypePaths(pt, paths, visited)
+		}
+	}
+	collectTypePaths(typ.ParadigmsPacked, paths, visited)
+}
+
+// loadDecl reads the declaration of function when the service was loaded from cache.
+func (f *Function) loadDecl() (err error) {
+	if f.decl != nil {
+		return
+	}
+	file, _, readErr := f.mod.sources.ReadFile(f.path, filepath.Base(f.filename))
+	if readErr != nil {
+		err = readErr
+		return
+	}
+	for _, decl := range file.Decls {
+		funcDecl, ok := decl.(*ast.FuncDecl)
+		if ok && funcDecl.Recv == nil && funcDecl.Name.Name == f.Ident {
+			f.file = file
+			f.decl = funcDecl
+			f.imports = newImportsFromAstFileImports(file.Imports)
+			return
+		}
+	}
+	err = errors.Warning("forg: function was not found").WithMeta("file", f.filename).WithMeta("function", f.Ident)
+	return
+}
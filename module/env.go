@@ -3,6 +3,8 @@ package module
 import (
 	"github.com/aacfactory/errors"
 	"github.com/aacfactory/forg/files"
+	"golang.org/x/mod/module"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
@@ -26,33 +28,81 @@ func GOROOT() (goroot string, has bool) {
 	return
 }
 
-var pkgDir = ""
-
-func initPkgDir() (err error) {
-	gopath, hasGOPATH := GOPATH()
-	if hasGOPATH {
-		pkgDir = filepath.ToSlash(filepath.Join(gopath, "pkg/mod"))
-		if !files.ExistFile(pkgDir) {
-			pkgDir = ""
-			err = errors.Warning("forg: GOPATH was found but no 'pkg/mod' dir")
+// goEnv returns the value of go env key, the os environment is first, then the go env file which is written by 'go env -w'.
+// An empty value is same as unset.
+func goEnv(key string) (value string, has bool) {
+	value = strings.TrimSpace(os.Getenv(key))
+	if value != "" {
+		has = true
+		return
+	}
+	filename := os.Getenv("GOENV")
+	if filename == "off" {
+		return
+	}
+	if filename == "" {
+		configDir, configDirErr := os.UserConfigDir()
+		if configDirErr != nil {
 			return
 		}
+		filename = filepath.Join(configDir, "go", "env")
+	}
+	data, readErr := os.ReadFile(filename)
+	if readErr != nil {
 		return
 	}
-	goroot, hasGOROOT := GOROOT()
-	if hasGOROOT {
-		pkgDir = filepath.ToSlash(filepath.Join(goroot, "pkg/mod"))
-		if !files.ExistFile(pkgDir) {
-			pkgDir = ""
-			err = errors.Warning("forg: GOROOT was found but no 'pkg/mod' dir")
-			return
+	for _, line := range strings.Split(string(data), "\n") {
+		k, v, ok := strings.Cut(strings.TrimSpace(line), "=")
+		if ok && strings.TrimSpace(k) == key {
+			value = strings.TrimSpace(v)
+			has = value != ""
+		}
+	}
+	return
+}
+
+// GOMODCACHE returns the module cache dir in the way of 'go env GOMODCACHE',
+// it is GOMODCACHE, or pkg/mod in the first entry of GOPATH, and GOPATH is ~/go by default.
+func GOMODCACHE() (dir string, has bool) {
+	dir, has = goEnv("GOMODCACHE")
+	if has {
+		dir = filepath.ToSlash(dir)
+		return
+	}
+	gopath, hasGOPATH := goEnv("GOPATH")
+	if hasGOPATH {
+		for _, entry := range filepath.SplitList(gopath) {
+			entry = strings.TrimSpace(entry)
+			if entry != "" {
+				dir = filepath.ToSlash(filepath.Join(entry, "pkg", "mod"))
+				has = true
+				return
+			}
 		}
 		return
 	}
-	if !hasGOPATH && !hasGOROOT {
-		err = errors.Warning("forg: GOPATH and GOROOT were not found")
+	home, homeErr := os.UserHomeDir()
+	if homeErr != nil || home == "" {
 		return
 	}
+	dir = filepath.ToSlash(filepath.Join(home, "go", "pkg", "mod"))
+	has = true
+	return
+}
+
+var pkgDir = ""
+
+func initPkgDir(fsys fs.FS) (err error) {
+	dir, has := GOMODCACHE()
+	if !has {
+		err = errors.Warning("forg: GOMODCACHE, GOPATH and home dir were not found")
+		return
+	}
+	if !files.Exist(fsys, dir) {
+		err = errors.Warning("forg: module cache dir was not found").WithMeta("dir", dir)
+		return
+	}
+	pkgDir = dir
 	return
 }
 
@@ -60,3 +110,20 @@ func PKG() (pkg string) {
 	pkg = pkgDir
 	return
 }
+
+// ModuleCacheDir returns the dir of module in module cache, upper case letters of path and version are escaped like the module cache does,
+// such as github.com/BurntSushi/toml@v1.2.0 is in github.com/!burnt!sushi/toml@v1.2.0.
+func ModuleCacheDir(path string, version string) (dir string, err error) {
+	escapedPath, escapePathErr := module.EscapePath(path)
+	if escapePathErr != nil {
+		err = errors.Warning("forg: escape module path failed").WithCause(escapePathErr).WithMeta("path", path)
+		return
+	}
+	escapedVersion, escapeVersionErr := module.EscapeVersion(version)
+	if escapeVersionErr != nil {
+		err = errors.Warning("forg: escape module version failed").WithCause(escapeVersionErr).WithMeta("path", path).WithMeta("version", version)
+		return
+	}
+	dir = filepath.ToSlash(filepath.Join(PKG(), filepath.FromSlash(escapedPath)+"@"+escapedVersion))
+	return
+}
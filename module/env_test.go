@@ -0,0 +1,47 @@
+package module_test
+
+import (
+	"github.com/aacfactory/forg/module"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGOMODCACHE(t *testing.T) {
+	t.Setenv("GOENV", "off")
+	t.Setenv("GOMODCACHE", "/cache/mod")
+	t.Setenv("GOPATH", "/a"+string(os.PathListSeparator)+"/b")
+	if dir, has := module.GOMODCACHE(); !has || dir != "/cache/mod" {
+		t.Errorf("expect GOMODCACHE, got %s", dir)
+	}
+	t.Setenv("GOMODCACHE", "")
+	if dir, has := module.GOMODCACHE(); !has || dir != filepath.ToSlash(filepath.Join("/a", "pkg", "mod")) {
+		t.Errorf("expect first entry of GOPATH, got %s", dir)
+	}
+	t.Setenv("GOPATH", "")
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	if dir, has := module.GOMODCACHE(); !has || dir != filepath.ToSlash(filepath.Join(home, "go", "pkg", "mod")) {
+		t.Errorf("expect ~/go/pkg/mod, got %s", dir)
+	}
+	env := filepath.Join(t.TempDir(), "env")
+	if err := os.WriteFile(env, []byte("GOMODCACHE=/written/mod\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GOENV", env)
+	if dir, has := module.GOMODCACHE(); !has || dir != "/written/mod" {
+		t.Errorf("expect GOMODCACHE of go env file, got %s", dir)
+	}
+}
+
+func TestModuleCacheDir(t *testing.T) {
+	dir, err := module.ModuleCacheDir("github.com/BurntSushi/toml", "v1.2.0")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if !strings.HasSuffix(dir, "github.com/!burnt!sushi/toml@v1.2.0") {
+		t.Errorf("unexpected dir: %s", dir)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"github.com/aacfactory/errors"
 	"go/ast"
+	gotypes "go/types"
 	"io"
 	"reflect"
 	"strings"
@@ -54,6 +55,11 @@ func (f *Function) HostServiceName() (name string) {
 	return
 }
 
+func (f *Function) Filename() (filename string) {
+	filename = f.filename
+	return
+}
+
 func (f *Function) Name() (name string) {
 	name = f.Annotations["fn"]
 	return
@@ -199,24 +205,43 @@ func (f *Function) Parse(ctx context.Context) (err error) {
 			WithMeta("service", f.hostServiceName).WithMeta("function", f.Ident).WithMeta("file", f.filename)
 		return
 	}
-	if f.decl.Type.TypeParams != nil && f.decl.Type.TypeParams.List != nil && len(f.decl.Type.TypeParams.List) > 0 {
-		err = errors.Warning("forg: parse function failed").WithCause(errors.Warning("function can not use paradigm")).
-			WithMeta("service", f.hostServiceName).WithMeta("function", f.Ident).WithMeta("file", f.filename)
+	if f.mod.cache == nil {
+		err = f.parse(ctx)
+		return
+	}
+	if f.loadCached(ctx) {
 		return
 	}
+	err = f.parse(ctx)
+	if err != nil {
+		return
+	}
+	f.storeCached(ctx)
+	return
+}
 
-	// params
-	params := f.decl.Type.Params
-	if params == nil || params.List == nil || len(params.List) == 0 || len(params.List) > 2 {
-		err = errors.Warning("forg: parse function failed").WithCause(errors.Warning("params length must be one or two")).
+func (f *Function) parse(ctx context.Context) (err error) {
+	if loadErr := f.loadDecl(); loadErr != nil {
+		err = errors.Warning("forg: parse function failed").WithCause(loadErr).
+			WithMeta("service", f.hostServiceName).WithMeta("function", f.Ident).WithMeta("file", f.filename)
+		return
+	}
+	if violation, violated := paradigmProblems(f.decl).first(); violated {
+		err = errors.Warning("forg: parse function failed").WithCause(violation.err()).
 			WithMeta("service", f.hostServiceName).WithMeta("function", f.Ident).WithMeta("file", f.filename)
 		return
 	}
-	if !f.mod.types.isContextType(params.List[0].Type, f.imports) {
-		err = errors.Warning("forg: parse function failed").WithCause(errors.Warning("first param must be context.Context")).
+	if f.mod.types.packages != nil {
+		err = f.parseByPackages(ctx)
+		return
+	}
+	if violation, violated := f.mod.types.signatureProblems(f.decl, f.imports).first(); violated {
+		err = errors.Warning("forg: parse function failed").WithCause(violation.err()).
 			WithMeta("service", f.hostServiceName).WithMeta("function", f.Ident).WithMeta("file", f.filename)
 		return
 	}
+	// params
+	params := f.decl.Type.Params
 	if len(params.List) == 2 {
 		param, parseParamErr := f.parseField(ctx, params.List[1])
 		if parseParamErr != nil {
@@ -228,23 +253,7 @@ func (f *Function) Parse(ctx context.Context) (err error) {
 	}
 	// results
 	results := f.decl.Type.Results
-	if results == nil || results.List == nil || len(results.List) == 0 || len(results.List) > 2 {
-		err = errors.Warning("forg: parse function failed").WithCause(errors.Warning("results length must be one or two")).
-			WithMeta("service", f.hostServiceName).WithMeta("function", f.Ident).WithMeta("file", f.filename)
-		return
-	}
-	if len(results.List) == 1 {
-		if !f.mod.types.isCodeErrorType(results.List[0].Type, f.imports) {
-			err = errors.Warning("forg: parse function failed").WithCause(errors.Warning("the last results must github.com/aacfactory/errors.CodeError")).
-				WithMeta("service", f.hostServiceName).WithMeta("function", f.Ident).WithMeta("file", f.filename)
-			return
-		}
-	} else {
-		if !f.mod.types.isCodeErrorType(results.List[1].Type, f.imports) {
-			err = errors.Warning("forg: parse function failed").WithCause(errors.Warning("the last results must github.com/aacfactory/errors.CodeError")).
-				WithMeta("service", f.hostServiceName).WithMeta("function", f.Ident).WithMeta("file", f.filename)
-			return
-		}
+	if len(results.List) == 2 {
 		result, parseResultErr := f.parseField(ctx, results.List[0])
 		if parseResultErr != nil {
 			err = errors.Warning("forg: parse function failed").WithCause(parseResultErr).
@@ -316,6 +325,89 @@ func (f *Function) parseFieldType(ctx context.Context, e ast.Expr) (typ *Type, e
 	return
 }
 
+// parseByPackages parses the signature by go/types, see packagesLoader.
+func (f *Function) parseByPackages(ctx context.Context) (err error) {
+	ctx, _ = f.mod.types.withGeneration(ctx)
+	sig, sigErr := f.mod.types.packages.Signature(ctx, f.path, f.Ident)
+	if sigErr != nil {
+		err = errors.Warning("forg: parse function failed").WithCause(sigErr).
+			WithMeta("service", f.hostServiceName).WithMeta("function", f.Ident).WithMeta("file", f.filename)
+		return
+	}
+	// params
+	params := sig.Params()
+	if params.Len() == 0 || params.Len() > 2 {
+		err = errors.Warning("forg: parse function failed").WithCause(errors.Warning(paramsLengthMessage)).
+			WithMeta("service", f.hostServiceName).WithMeta("function", f.Ident).WithMeta("file", f.filename)
+		return
+	}
+	if !isNamedType(params.At(0).Type(), "context", "Context") {
+		err = errors.Warning("forg: parse function failed").WithCause(errors.Warning(contextParamMessage)).
+			WithMeta("service", f.hostServiceName).WithMeta("function", f.Ident).WithMeta("file", f.filename)
+		return
+	}
+	if params.Len() == 2 {
+		param, parseParamErr := f.parseVar(ctx, params.At(1))
+		if parseParamErr != nil {
+			err = errors.Warning("forg: parse function failed").WithCause(parseParamErr).
+				WithMeta("service", f.hostServiceName).WithMeta("function", f.Ident).WithMeta("file", f.filename)
+			return
+		}
+		f.Param = param
+	}
+	// results
+	results := sig.Results()
+	if results.Len() == 0 || results.Len() > 2 {
+		err = errors.Warning("forg: parse function failed").WithCause(errors.Warning(resultsLengthMessage)).
+			WithMeta("service", f.hostServiceName).WithMeta("function", f.Ident).WithMeta("file", f.filename)
+		return
+	}
+	if !isNamedType(results.At(results.Len()-1).Type(), "github.com/aacfactory/errors", "CodeError") {
+		err = errors.Warning("forg: parse function failed").WithCause(errors.Warning(codeErrorResultFailure)).
+			WithMeta("service", f.hostServiceName).WithMeta("function", f.Ident).WithMeta("file", f.filename)
+		return
+	}
+	if results.Len() == 2 {
+		result, parseResultErr := f.parseVar(ctx, results.At(0))
+		if parseResultErr != nil {
+			err = errors.Warning("forg: parse function failed").WithCause(parseResultErr).
+				WithMeta("service", f.hostServiceName).WithMeta("function", f.Ident).WithMeta("file", f.filename)
+			return
+		}
+		f.Result = result
+	}
+	return
+}
+
+func (f *Function) parseVar(ctx context.Context, v *gotypes.Var) (field *FunctionField, err error) {
+	name := v.Name()
+	if name == "" || name == "_" {
+		err = errors.Warning("forg: field must has only one name")
+		return
+	}
+	named, isNamed := unalias(v.Type()).(*gotypes.Named)
+	if !isNamed || (named.TypeArgs() != nil && named.TypeArgs().Len() > 0) {
+		err = errors.Warning("forg: parse field failed").WithMeta("field", name).
+			WithCause(errors.Warning("forg: field type only support no paradigms value object or array").WithMeta("type", v.Type().String()))
+		return
+	}
+	typ, parseTypeErr := f.mod.types.parseGoType(ctx, named)
+	if parseTypeErr != nil {
+		err = errors.Warning("forg: parse field failed").WithMeta("field", name).WithCause(parseTypeErr)
+		return
+	}
+	if _, isBasic := typ.Basic(); isBasic {
+		err = errors.Warning("forg: parse field failed").WithMeta("field", name).
+			WithCause(errors.Warning("forg: field type only support value object"))
+		return
+	}
+	field = &FunctionField{
+		Name: name,
+		Type: typ,
+	}
+	return
+}
+
 func (f *Function) Handle(ctx context.Context) (result interface{}, err error) {
 	err = f.Parse(ctx)
 	if err != nil {
@@ -0,0 +1,348 @@
+package module
+
+import (
+	"context"
+	"fmt"
+	"github.com/aacfactory/errors"
+	"github.com/aacfactory/forg/files"
+	"go/ast"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	LintError   = "error"
+	LintWarning = "warning"
+)
+
+type Issue struct {
+	Level    string `json:"level"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Service  string `json:"service,omitempty"`
+	Function string `json:"function,omitempty"`
+	Message  string `json:"message"`
+}
+
+func (issue *Issue) String() (s string) {
+	s = fmt.Sprintf("%s:%d:%d: %s: %s", issue.File, issue.Line, issue.Column, issue.Level, issue.Message)
+	return
+}
+
+type Issues []*Issue
+
+func (issues Issues) Len() int {
+	return len(issues)
+}
+
+func (issues Issues) Less(i, j int) bool {
+	if issues[i].File != issues[j].File {
+		return issues[i].File < issues[j].File
+	}
+	if issues[i].Line != issues[j].Line {
+		return issues[i].Line < issues[j].Line
+	}
+	return issues[i].Column < issues[j].Column
+}
+
+func (issues Issues) Swap(i, j int) {
+	issues[i], issues[j] = issues[j], issues[i]
+	return
+}
+
+// Errors returns the number of issues which level is error.
+func (issues Issues) Errors() (n int) {
+	for _, issue := range issues {
+		if issue.Level == LintError {
+			n++
+		}
+	}
+	return
+}
+
+// Lint walks all services and functions and collects every violation instead of stopping at the first one.
+// The module must be parsed.
+func (mod *Module) Lint(ctx context.Context) (issues Issues, err error) {
+	if mod.Replace != nil {
+		issues, err = mod.Replace.Lint(ctx)
+		return
+	}
+	mod.locker.Lock()
+	parsed := mod.parsed
+	mod.locker.Unlock()
+	if !parsed {
+		err = errors.Warning("forg: lint failed").WithCause(errors.Warning("forg: module was not parsed")).WithMeta("mod", mod.Dir)
+		return
+	}
+	modules := mod.Config().Modules
+	servicesDir := filepath.ToSlash(filepath.Join(mod.Dir, modules))
+	entries, readErr := files.ReadDir(mod.fsys, servicesDir)
+	if readErr != nil {
+		err = errors.Warning("forg: lint failed").WithCause(readErr).WithMeta("dir", servicesDir)
+		return
+	}
+	l := &linter{
+		mod:      mod,
+		fset:     token.NewFileSet(),
+		services: make(map[string]string),
+		issues:   make(Issues, 0, 1),
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		if ctx.Err() != nil {
+			err = errors.Warning("forg: lint failed").WithCause(ctx.Err())
+			return
+		}
+		l.lintService(ctx, entry.Name(), fmt.Sprintf("%s/%s/%s", mod.Path, modules, entry.Name()), filepath.ToSlash(filepath.Join(servicesDir, entry.Name())))
+	}
+	issues = l.issues
+	sort.Sort(issues)
+	return
+}
+
+type linter struct {
+	mod      *Module
+	fset     *token.FileSet
+	services map[string]string
+	issues   Issues
+}
+
+func (l *linter) report(level string, pos token.Pos, service string, function string, message string) {
+	position := l.fset.Position(pos)
+	l.issues = append(l.issues, &Issue{
+		Level:    level,
+		File:     filepath.ToSlash(position.Filename),
+		Line:     position.Line,
+		Column:   position.Column,
+		Service:  service,
+		Function: function,
+		Message:  message,
+	})
+}
+
+// reportProblems reports problems which are found by rules of parsing as errors.
+func (l *linter) reportProblems(ps problems, service string, function string) {
+	for _, p := range ps {
+		message := p.message
+		if p.cause != nil {
+			message = p.message + ": " + ErrorMessages(p.cause, false)
+		}
+		l.report(LintError, p.pos, service, function, message)
+	}
+}
+
+// parseFile parses the file, syntax errors are reported and file is nil when it can not be parsed.
+func (l *linter) parseFile(filename string, service string) (file *ast.File) {
+	src, readErr := files.ReadFile(l.mod.fsys, filename)
+	if readErr != nil {
+		l.issues = append(l.issues, &Issue{
+			Level:   LintError,
+			File:    filename,
+			Line:    0,
+			Column:  0,
+			Service: service,
+			Message: readErr.Error(),
+		})
+		return
+	}
+	file, parseErr := parser.ParseFile(l.fset, filename, src, parser.AllErrors|parser.ParseComments)
+	if parseErr == nil {
+		return
+	}
+	file = nil
+	list, isList := parseErr.(scanner.ErrorList)
+	if !isList {
+		l.issues = append(l.issues, &Issue{
+			Level:   LintError,
+			File:    filename,
+			Line:    0,
+			Column:  0,
+			Service: service,
+			Message: parseErr.Error(),
+		})
+		return
+	}
+	for _, item := range list {
+		l.issues = append(l.issues, &Issue{
+			Level:   LintError,
+			File:    filepath.ToSlash(item.Pos.Filename),
+			Line:    item.Pos.Line,
+			Column:  item.Pos.Column,
+			Service: service,
+			Message: item.Msg,
+		})
+	}
+	return
+}
+
+// sourceFiles returns go files in dir which match build constraints, tests and generated files are excluded.
+func (l *linter) sourceFiles(dir string) (filenames []string) {
+	entries, readErr := files.ReadDir(l.mod.fsys, dir)
+	if readErr != nil {
+		return
+	}
+	output := l.mod.Config().Output
+	filenames = make([]string, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || name == output {
+			continue
+		}
+		if matched, _ := l.mod.sources.match(dir, name); !matched {
+			continue
+		}
+		filenames = append(filenames, filepath.ToSlash(filepath.Join(dir, name)))
+	}
+	return
+}
+
+func (l *linter) lintService(ctx context.Context, dirName string, path string, dir string) {
+	docFilename := filepath.ToSlash(filepath.Join(dir, "doc.go"))
+	if !files.Exist(l.mod.fsys, docFilename) {
+		return
+	}
+	doc := l.parseFile(docFilename, dirName)
+	if doc == nil || doc.Doc == nil || !strings.Contains(doc.Doc.Text(), "@service") {
+		return
+	}
+	annotations, annotationsErr := ParseAnnotations(doc.Doc.Text())
+	if annotationsErr != nil {
+		l.report(LintError, doc.Doc.Pos(), dirName, "", "invalid annotations of service: "+ErrorMessages(annotationsErr, false))
+		return
+	}
+	name, _ := annotations.Get("service")
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		l.report(LintError, doc.Doc.Pos(), dirName, "", "@service must have a name")
+		return
+	}
+	l.reportProblems(servicePackageProblems(doc, dirName), name, "")
+	if existed, has := l.services[name]; has {
+		l.report(LintError, doc.Doc.Pos(), name, "", fmt.Sprintf("service %s was declared in %s", name, existed))
+	} else {
+		l.services[name] = docFilename
+	}
+	if title, _ := annotations.Get("title"); strings.TrimSpace(title) == "" {
+		l.report(LintWarning, doc.Doc.Pos(), name, "", "service has no @title annotation")
+	}
+	functions := make(map[string]string)
+	for _, filename := range l.sourceFiles(dir) {
+		if ctx.Err() != nil {
+			return
+		}
+		file := doc
+		if filename != docFilename {
+			file = l.parseFile(filename, name)
+		}
+		if file == nil {
+			continue
+		}
+		imports := newImportsFromAstFileImports(file.Imports)
+		for _, decl := range file.Decls {
+			funcDecl, isFunc := decl.(*ast.FuncDecl)
+			if !isFunc {
+				continue
+			}
+			if _, isFunction := functionDoc(funcDecl); !isFunction {
+				continue
+			}
+			l.lintFunction(ctx, name, path, filename, imports, funcDecl, functions)
+		}
+	}
+	componentsDir := filepath.ToSlash(filepath.Join(dir, "components"))
+	if files.Exist(l.mod.fsys, componentsDir) {
+		for _, filename := range l.sourceFiles(componentsDir) {
+			file := l.parseFile(filename, name)
+			if file == nil {
+				continue
+			}
+			l.lintComponents(name, file)
+		}
+	}
+}
+
+func (l *linter) lintFunction(ctx context.Context, service string, path string, filename string, imports Imports, decl *ast.FuncDecl, functions map[string]string) {
+	ident := decl.Name.Name
+	if decl.Recv != nil {
+		l.report(LintWarning, decl.Name.Pos(), service, ident, "@fn on method is ignored")
+		return
+	}
+	l.reportProblems(functionNameProblems(decl), service, ident)
+	annotations, annotationsErr := ParseAnnotations(decl.Doc.Text())
+	if annotationsErr != nil {
+		l.report(LintError, decl.Doc.Pos(), service, ident, "invalid annotations of func: "+ErrorMessages(annotationsErr, false))
+		return
+	}
+	name, _ := annotations.Get("fn")
+	name = strings.TrimSpace(name)
+	if name == "" {
+		l.report(LintError, decl.Doc.Pos(), service, ident, "@fn must have a name")
+	} else if existed, has := functions[name]; has {
+		l.report(LintError, decl.Doc.Pos(), service, ident, fmt.Sprintf("fn %s was declared in %s", name, existed))
+	} else {
+		functions[name] = filename
+	}
+	if title, _ := annotations.Get("title"); strings.TrimSpace(title) == "" {
+		l.report(LintWarning, decl.Doc.Pos(), service, ident, "func has no @title annotation")
+	}
+	if paradigm := paradigmProblems(decl); len(paradigm) > 0 {
+		l.reportProblems(paradigm, service, ident)
+		return
+	}
+	function := &Function{
+		mod:             l.mod,
+		hostServiceName: service,
+		path:            path,
+		filename:        filename,
+		file:            nil,
+		imports:         imports,
+		decl:            decl,
+		Ident:           ident,
+		Annotations:     annotations,
+	}
+	if _, _, timeoutErr := function.Timeout(); timeoutErr != nil {
+		l.report(LintError, decl.Doc.Pos(), service, ident, fmt.Sprintf("@timeout %s is not a duration", annotations["timeout"]))
+	}
+	l.reportProblems(l.mod.types.signatureProblems(decl, imports), service, ident)
+	params := decl.Type.Params
+	if params != nil && len(params.List) == 2 {
+		l.lintField(ctx, function, "param", params.List[1])
+	}
+	results := decl.Type.Results
+	if results != nil && len(results.List) == 2 {
+		l.lintField(ctx, function, "result", results.List[0])
+	}
+}
+
+func (l *linter) lintField(ctx context.Context, function *Function, kind string, field *ast.Field) {
+	_, parseErr := function.parseField(ctx, field)
+	if parseErr != nil {
+		l.report(LintError, field.Type.Pos(), function.hostServiceName, function.Ident, fmt.Sprintf("invalid %s type: %s", kind, ErrorMessages(parseErr, false)))
+	}
+}
+
+func (l *linter) lintComponents(service string, file *ast.File) {
+	for _, decl := range file.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok {
+			continue
+		}
+		for _, spec := range genDecl.Specs {
+			ts, tsOk := spec.(*ast.TypeSpec)
+			if !tsOk {
+				continue
+			}
+			if _, isComponent := componentDoc(genDecl, ts); !isComponent {
+				continue
+			}
+			l.reportProblems(componentProblems(ts), service, "")
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package module
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/aacfactory/errors"
+	"sort"
+	"strings"
+)
+
+type errorMessage struct {
+	Message string            `json:"message"`
+	Meta    map[string]string `json:"meta"`
+	Cause   *errorMessage     `json:"cause"`
+}
+
+// ErrorMessages returns messages of err and its causes which are joined by ": ", forg prefixes and repeated messages are dropped.
+// Metas are appended to messages in parentheses when withMeta is true, they are sorted by keys.
+func ErrorMessages(err error, withMeta bool) (s string) {
+	if err == nil {
+		return
+	}
+	p, encodeErr := json.Marshal(errors.Map(err))
+	if encodeErr != nil {
+		s = strings.TrimPrefix(err.Error(), "forg: ")
+		return
+	}
+	message := &errorMessage{}
+	decodeErr := json.Unmarshal(p, message)
+	if decodeErr != nil {
+		message = &errorMessage{
+			Message: errors.Map(err).Message(),
+			Meta:    nil,
+			Cause:   nil,
+		}
+	}
+	items := make([]string, 0, 1)
+	for message != nil {
+		item := strings.TrimPrefix(message.Message, "forg: ")
+		if withMeta && len(message.Meta) > 0 {
+			metas := make([]string, 0, len(message.Meta))
+			for k, v := range message.Meta {
+				metas = append(metas, fmt.Sprintf("%s=%s", k, v))
+			}
+			sort.Strings(metas)
+			item = fmt.Sprintf("%s (%s)", item, strings.Join(metas, ", "))
+		}
+		if item != "" && (len(items) == 0 || items[len(items)-1] != item) {
+			items = append(items, item)
+		}
+		message = message.Cause
+	}
+	s = strings.Join(items, ": ")
+	return
+}
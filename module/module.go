@@ -5,22 +5,151 @@ import (
 	"context"
 	"fmt"
 	"github.com/aacfactory/errors"
+	"github.com/aacfactory/forg/configs"
 	"github.com/aacfactory/forg/files"
+	"go/build"
 	"golang.org/x/mod/modfile"
 	"golang.org/x/sync/singleflight"
-	"os"
+	"io/fs"
 	"path/filepath"
 	"sort"
 	"strings"
 	"sync"
 )
 
-func New(path string) (v *Module, err error) {
-	v, err = NewWithWork(path, "")
+type Options struct {
+	Config *configs.Config
+	// Packages builds types from go/types information which is loaded by go command, instead of ast objects.
+	Packages bool
+	// GOOS, GOARCH and Tags select source files by build constraints, GOOS and GOARCH are same as go env when they are empty.
+	GOOS   string
+	GOARCH string
+	Tags   []string
+	// Cache is the root dir of parse cache, see CacheDir, the cache is disabled when it is empty.
+	Cache string
+	// FS is the file system where sources are read, names of it are made by files.Name, it is the disk when it is nil.
+	FS fs.FS
+}
+
+type Option func(options *Options) (err error)
+
+func WithConfig(config *configs.Config) Option {
+	return func(options *Options) (err error) {
+		if config == nil {
+			err = errors.Warning("forg: config option is invalid")
+			return
+		}
+		validErr := config.Validate()
+		if validErr != nil {
+			err = errors.Warning("forg: config option is invalid").WithCause(validErr)
+			return
+		}
+		options.Config = config
+		return
+	}
+}
+
+// WithPackagesLoader uses go/packages and go/types to build types of functions, it needs go command.
+func WithPackagesLoader() Option {
+	return func(options *Options) (err error) {
+		options.Packages = true
+		return
+	}
+}
+
+// WithGOOS selects source files for the os, such as linux and windows.
+func WithGOOS(goos string) Option {
+	return func(options *Options) (err error) {
+		goos = strings.TrimSpace(goos)
+		if goos == "" {
+			err = errors.Warning("forg: GOOS option is invalid")
+			return
+		}
+		options.GOOS = goos
+		return
+	}
+}
+
+// WithGOARCH selects source files for the architecture, such as amd64 and arm64.
+func WithGOARCH(goarch string) Option {
+	return func(options *Options) (err error) {
+		goarch = strings.TrimSpace(goarch)
+		if goarch == "" {
+			err = errors.Warning("forg: GOARCH option is invalid")
+			return
+		}
+		options.GOARCH = goarch
+		return
+	}
+}
+
+// WithTags selects source files by the build tags, it is same as -tags of go command.
+func WithTags(tags ...string) Option {
+	return func(options *Options) (err error) {
+		options.Tags = append(options.Tags, tags...)
+		return
+	}
+}
+
+// WithCache stores parsed services and types of functions in the dir, so unchanged packages are not parsed again.
+func WithCache(dir string) Option {
+	return func(options *Options) (err error) {
+		dir = strings.TrimSpace(dir)
+		if dir == "" || !filepath.IsAbs(dir) {
+			err = errors.Warning("forg: cache option is invalid, it must be an absolute path").WithMeta("cache", dir)
+			return
+		}
+		options.Cache = dir
+		return
+	}
+}
+
+// WithFS reads go.mod, go.work and sources from the file system instead of the disk, such as the unsaved buffers of an editor.
+// Names of the file system are made by files.Name of absolute paths, it can not be used with WithPackagesLoader.
+func WithFS(fsys fs.FS) Option {
+	return func(options *Options) (err error) {
+		if fsys == nil {
+			err = errors.Warning("forg: fs option is invalid")
+			return
+		}
+		options.FS = fsys
+		return
+	}
+}
+
+func New(path string, options ...Option) (v *Module, err error) {
+	v, err = NewWithWork(path, "", options...)
 	return
 }
 
-func NewWithWork(path string, workPath string) (v *Module, err error) {
+func NewWithWork(path string, workPath string, options ...Option) (v *Module, err error) {
+	opt := &Options{
+		Config:   configs.Default(),
+		Packages: false,
+		GOOS:     "",
+		GOARCH:   "",
+		Tags:     nil,
+		Cache:    "",
+		FS:       nil,
+	}
+	if options != nil && len(options) > 0 {
+		for _, option := range options {
+			optionErr := option(opt)
+			if optionErr != nil {
+				err = errors.Warning("forg: new module failed").WithCause(optionErr)
+				return
+			}
+		}
+	}
+	fsys := opt.FS
+	if fsys == nil {
+		fsys = files.Disk()
+	} else if opt.Packages {
+		// go command reads the disk only
+		err = errors.Warning("forg: new module failed").
+			WithCause(errors.Warning("forg: packages loader can not be used with fs option"))
+		return
+	}
 	path = filepath.ToSlash(path)
 	if !filepath.IsAbs(path) {
 		absolute, absoluteErr := filepath.Abs(path)
@@ -31,16 +160,20 @@ func NewWithWork(path string, workPath string) (v *Module, err error) {
 		}
 		path = absolute
 	}
-	if !files.ExistFile(path) {
+	if !files.Exist(fsys, path) {
 		err = errors.Warning("forg: new module failed").
 			WithCause(errors.Warning("forg: file was not found").WithMeta("path", path))
 		return
 	}
-	pkgErr := initPkgDir()
+	pkgErr := initPkgDir(fsys)
 	if pkgErr != nil {
-		err = errors.Warning("forg: new module failed").
-			WithCause(pkgErr)
-		return
+		// module cache is not required when requires are vendored
+		vendored, vendorErr := vendorEnabled(fsys, filepath.Dir(path))
+		if vendorErr != nil || !vendored {
+			err = errors.Warning("forg: new module failed").
+				WithCause(pkgErr)
+			return
+		}
 	}
 	if workPath != "" {
 		work := &Work{
@@ -48,6 +181,7 @@ func NewWithWork(path string, workPath string) (v *Module, err error) {
 			Uses:     nil,
 			Replaces: nil,
 			parsed:   false,
+			fsys:     fsys,
 		}
 		parseWorkErr := work.Parse()
 		if parseWorkErr != nil {
@@ -64,7 +198,8 @@ func NewWithWork(path string, workPath string) (v *Module, err error) {
 		}
 		if v == nil {
 			err = errors.Warning("forg: new module failed").
-				WithCause(errors.Warning("can not find in workspace"))
+				WithCause(errors.Warning("forg: module is not used by the workspace, add it into the workspace or disable the workspace by GOWORK=off").
+					WithMeta("work", work.Filename).WithMeta("dir", dir))
 			return
 		}
 	} else {
@@ -81,21 +216,53 @@ func NewWithWork(path string, workPath string) (v *Module, err error) {
 			types:    nil,
 		}
 	}
+	v.config = opt.Config
+	v.loadPackages = opt.Packages
+	v.fsys = fsys
+	v.build = newBuildContext(opt.GOOS, opt.GOARCH, opt.Tags)
+	if opt.Cache != "" {
+		v.cache, err = openCache(opt.Cache)
+		if err != nil {
+			err = errors.Warning("forg: new module failed").WithCause(err)
+			return
+		}
+	}
 	return
 }
 
 type Module struct {
-	Dir      string
-	Path     string
-	Version  string
-	Requires Requires
-	Work     *Work
-	Replace  *Module
-	locker   sync.Locker
-	parsed   bool
-	sources  *Sources
-	services map[string]*Service
-	types    *Types
+	Dir          string
+	Path         string
+	Version      string
+	Requires     Requires
+	Work         *Work
+	Replace      *Module
+	Retracted    string
+	locker       sync.Locker
+	parsed       bool
+	sources      *Sources
+	services     map[string]*Service
+	rescan       bool
+	types        *Types
+	config       *configs.Config
+	loadPackages bool
+	selected     bool
+	build        *build.Context
+	cache        *Cache
+	fsys         fs.FS
+	// dependencies is the hash of files which decide versions of requires, see dependencyHash.
+	dependencies string
+	// requiresLocker guards Requires, Work and dependencies which are replaced by Reload.
+	requiresLocker sync.RWMutex
+}
+
+// Config returns the config of generating, the config of required modules is the config of host.
+func (mod *Module) Config() (config *configs.Config) {
+	config = mod.config
+	if config == nil {
+		config = configs.Default()
+	}
+	return
 }
 
 func (mod *Module) Parse(ctx context.Context) (err error) {
@@ -114,6 +281,14 @@ func (mod *Module) parse(ctx context.Context, host *Module) (err error) {
 	if mod.parsed {
 		return
 	}
+	if mod.fsys == nil {
+		// required modules are read from the file system of host
+		if host != nil {
+			mod.fsys = host.fsys
+		} else {
+			mod.fsys = files.Disk()
+		}
+	}
 	if mod.Replace != nil {
 		err = mod.Replace.parse(ctx, host)
 		if err != nil {
@@ -122,142 +297,147 @@ func (mod *Module) parse(ctx context.Context, host *Module) (err error) {
 		mod.parsed = true
 		return
 	}
-
-	modFilepath := filepath.ToSlash(filepath.Join(mod.Dir, "go.mod"))
-	if !files.ExistFile(modFilepath) {
-		err = errors.Warning("forg: parse mod failed").
-			WithCause(errors.Warning("forg: mod file was not found").
-				WithMeta("file", modFilepath))
-		return
-	}
-	modData, readModErr := os.ReadFile(modFilepath)
-	if readModErr != nil {
-		err = errors.Warning("forg: parse mod failed").
-			WithCause(errors.Warning("forg: read mod file failed").
-				WithCause(readModErr).
-				WithMeta("file", modFilepath))
+	if mod.selected {
+		mod.parseSelected(host)
 		return
 	}
-	mf, parseModErr := modfile.Parse(modFilepath, modData, nil)
+
+	mf, parseModErr := mod.parseModFile()
 	if parseModErr != nil {
-		err = errors.Warning("forg: parse mod failed").
-			WithCause(errors.Warning("forg: parse mod file failed").WithCause(parseModErr).WithMeta("file", modFilepath))
+		err = parseModErr
 		return
 	}
 	mod.Path = mf.Module.Mod.Path
 	mod.Version = mf.Module.Mod.Version
-	mod.Requires = make([]*Module, 0, 1)
-	if mf.Require != nil && len(mf.Require) > 0 {
-		for _, require := range mf.Require {
-			if mod.Work != nil {
-				use, used := mod.Work.Use(require.Mod.Path)
-				if used {
-					mod.Requires = append(mod.Requires, use)
-					continue
+	if host == nil {
+		// requires of main module are the build list, so every type is found in the version which is used by go command.
+		mod.Requires, err = mod.buildList(mf)
+		if err != nil {
+			err = errors.Warning("forg: parse mod failed").WithCause(err).WithMeta("mod", mod.Path)
+			return
+		}
+		mod.dependencies = mod.dependencyHash(mod.Work)
+	} else {
+		mod.Requires = make([]*Module, 0, 1)
+		if mf.Require != nil && len(mf.Require) > 0 {
+			for _, require := range mf.Require {
+				if mod.Work != nil {
+					use, used := mod.Work.Use(require.Mod.Path)
+					if used {
+						mod.Requires = append(mod.Requires, use)
+						continue
+					}
 				}
+				requireDir, requireDirErr := ModuleCacheDir(require.Mod.Path, require.Mod.Version)
+				if requireDirErr != nil {
+					err = errors.Warning("forg: parse mod failed").WithCause(requireDirErr).WithMeta("mod", mod.Path)
+					return
+				}
+
+				mod.Requires = append(mod.Requires, &Module{
+					Dir:      requireDir,
+					Path:     require.Mod.Path,
+					Version:  require.Mod.Version,
+					Requires: nil,
+					Work:     nil,
+					Replace:  nil,
+					locker:   &sync.Mutex{},
+					parsed:   false,
+					services: nil,
+					types:    nil,
+				})
 			}
-			requireDir := filepath.ToSlash(filepath.Join(PKG(), fmt.Sprintf("%s@%s", require.Mod.Path, require.Mod.Version)))
-
-			mod.Requires = append(mod.Requires, &Module{
-				Dir:      requireDir,
-				Path:     require.Mod.Path,
-				Version:  require.Mod.Version,
-				Requires: nil,
-				Work:     nil,
-				Replace:  nil,
-				locker:   &sync.Mutex{},
-				parsed:   false,
-				services: nil,
-				types:    nil,
-			})
-		}
-	}
-	if mf.Replace != nil && len(mf.Replace) > 0 {
-		for _, replace := range mf.Replace {
-			replaceDir := ""
-			if replace.New.Version != "" {
-				replaceDir = filepath.Join(PKG(), fmt.Sprintf("%s@%s", replace.New.Path, replace.New.Version))
-			} else {
-				if filepath.IsAbs(replace.New.Path) {
-					replaceDir = replace.New.Path
+		}
+		if mf.Replace != nil && len(mf.Replace) > 0 {
+			for _, replace := range mf.Replace {
+				replaceDir := ""
+				if replace.New.Version != "" {
+					replaceDir, err = ModuleCacheDir(replace.New.Path, replace.New.Version)
+					if err != nil {
+						err = errors.Warning("forg: parse mod failed").WithCause(err).WithMeta("mod", mod.Path)
+						return
+					}
 				} else {
-					replaceDir = filepath.Join(mod.Dir, replace.New.Path)
+					if filepath.IsAbs(replace.New.Path) {
+						replaceDir = replace.New.Path
+					} else {
+						replaceDir = filepath.Join(mod.Dir, replace.New.Path)
+					}
+				}
+				replaceDir = filepath.ToSlash(replaceDir)
+				if !files.Exist(mod.fsys, replaceDir) {
+					err = errors.Warning("forg: parse mod failed").WithMeta("mod", mod.Path).
+						WithCause(errors.Warning("forg: replace dir was not found").WithMeta("replace", replaceDir))
+					return
+				}
+				replaceFile := filepath.ToSlash(filepath.Join(replaceDir, "go.mod"))
+				if !files.Exist(mod.fsys, replaceFile) {
+					err = errors.Warning("forg: parse mod failed").WithMeta("mod", mod.Path).
+						WithCause(errors.Warning("forg: replace mod file was not found").
+							WithMeta("replace", replaceFile))
+					return
+				}
+				replaceData, readReplaceErr := files.ReadFile(mod.fsys, replaceFile)
+				if readReplaceErr != nil {
+					err = errors.Warning("forg: parse mod failed").WithMeta("mod", mod.Path).
+						WithCause(errors.Warning("forg: read replace mod file failed").WithCause(readReplaceErr).WithMeta("replace", replaceFile))
+					return
+				}
+				rmf, parseReplaceModErr := modfile.Parse(replaceFile, replaceData, nil)
+				if parseReplaceModErr != nil {
+					err = errors.Warning("forg: parse mod failed").WithMeta("mod", mod.Path).
+						WithCause(errors.Warning("forg: parse replace mod file failed").WithCause(parseReplaceModErr).WithMeta("replace", replaceFile))
+					return
+				}
+				for _, require := range mod.Requires {
+					if require.Path == replace.Old.Path && require.Version == replace.Old.Version {
+						require.Replace = &Module{
+							Dir:      replaceDir,
+							Path:     rmf.Module.Mod.Path,
+							Version:  rmf.Module.Mod.Version,
+							Requires: nil,
+							Work:     nil,
+							Replace:  nil,
+							locker:   &sync.Mutex{},
+							parsed:   false,
+							services: nil,
+							types:    nil,
+						}
+					}
 				}
 			}
-			replaceDir = filepath.ToSlash(replaceDir)
-			if !files.ExistFile(replaceDir) {
-				err = errors.Warning("forg: parse mod failed").WithMeta("mod", mod.Path).
-					WithCause(errors.Warning("forg: replace dir was not found").WithMeta("replace", replaceDir))
-				return
-			}
-			replaceFile := filepath.ToSlash(filepath.Join(replaceDir, "go.mod"))
-			if !files.ExistFile(replaceFile) {
-				err = errors.Warning("forg: parse mod failed").WithMeta("mod", mod.Path).
-					WithCause(errors.Warning("forg: replace mod file was not found").
-						WithMeta("replace", replaceFile))
-				return
-			}
-			replaceData, readReplaceErr := os.ReadFile(replaceFile)
-			if readReplaceErr != nil {
-				err = errors.Warning("forg: parse mod failed").WithMeta("mod", mod.Path).
-					WithCause(errors.Warning("forg: read replace mod file failed").WithCause(readReplaceErr).WithMeta("replace", replaceFile))
-				return
+		}
+		work := mod.Work
+		if len(mod.Requires) > 0 {
+			if host.Replace != nil {
+				host = host.Replace
 			}
-			rmf, parseReplaceModErr := modfile.Parse(replaceFile, replaceData, nil)
-			if parseReplaceModErr != nil {
-				err = errors.Warning("forg: parse mod failed").WithMeta("mod", mod.Path).
-					WithCause(errors.Warning("forg: parse replace mod file failed").WithCause(parseReplaceModErr).WithMeta("replace", replaceFile))
-				return
+			hostRequires, hostWork := host.requirements()
+			if hostWork != nil && work == nil {
+				work = hostWork
 			}
-			for _, require := range mod.Requires {
-				if require.Path == replace.Old.Path && require.Version == replace.Old.Version {
-					require.Replace = &Module{
-						Dir:      replaceDir,
-						Path:     rmf.Module.Mod.Path,
-						Version:  rmf.Module.Mod.Version,
-						Requires: nil,
-						Work:     nil,
-						Replace:  nil,
-						locker:   &sync.Mutex{},
-						parsed:   false,
-						services: nil,
-						types:    nil,
+			if hostRequires != nil {
+				for i, require := range mod.Requires {
+					if require.Work != nil || require.Replace != nil {
+						continue
+					}
+					for _, hr := range hostRequires {
+						if require.Path == hr.Path {
+							mod.Requires[i] = hr
+							break
+						}
 					}
 				}
 			}
 		}
-	}
-	work := mod.Work
-	if host != nil && len(mod.Requires) > 0 {
-		if host.Replace != nil {
-			host = host.Replace
-		}
-		if host.Work != nil && work == nil {
-			work = host.Work
-		}
-		if host.Requires != nil {
+		if work != nil && len(work.Replaces) > 0 && len(mod.Requires) > 0 {
+			// replaces of workspace override replaces of module, and selected modules have been replaced by build list.
 			for i, require := range mod.Requires {
-				if require.Work != nil || require.Replace != nil {
+				if require.Work != nil || require.selected {
 					continue
 				}
-				for _, hr := range host.Requires {
-					if require.Path == hr.Path {
-						mod.Requires[i] = hr
-						break
-					}
-				}
-			}
-		}
-	}
-	if work != nil && len(work.Replaces) > 0 && len(mod.Requires) > 0 {
-		for i, require := range mod.Requires {
-			if require.Work != nil || require.Replace != nil {
-				continue
-			}
-			for _, replace := range work.Replaces {
-				if require.Path == replace.Path {
+				if replace, replaced := work.Replacement(require.Path, require.Version); replaced {
 					mod.Requires[i] = replace
-					break
 				}
 			}
 		}
@@ -268,22 +448,66 @@ func (mod *Module) parse(ctx context.Context, host *Module) (err error) {
 
 	if host != nil {
 		mod.types = host.types
+		mod.config = host.config
+		mod.dependencies = host.dependencies
+		mod.build = host.build
 	} else {
 		mod.types = &Types{
-			values: sync.Map{},
-			group:  singleflight.Group{},
+			values:     sync.Map{},
+			group:      singleflight.Group{},
+			packages:   nil,
+			locker:     sync.RWMutex{},
+			generation: 0,
+		}
+		if mod.loadPackages {
+			mod.types.packages = newPackagesLoader(mod)
 		}
 	}
 
-	mod.sources = newSource(mod.Path, mod.Dir)
+	mod.sources = newSource(mod.Path, mod.Dir, mod.build, mod.fsys)
 	mod.parsed = true
 	return
 }
 
+// requirements returns requires and work of the module, they are replaced by Reload while others are reading.
+func (mod *Module) requirements() (requires Requires, work *Work) {
+	mod.requiresLocker.RLock()
+	requires = mod.Requires
+	work = mod.Work
+	mod.requiresLocker.RUnlock()
+	return
+}
+
+// parseModFile reads go.mod of the module.
+func (mod *Module) parseModFile() (mf *modfile.File, err error) {
+	modFilepath := filepath.ToSlash(filepath.Join(mod.Dir, "go.mod"))
+	if !files.Exist(mod.fsys, modFilepath) {
+		err = errors.Warning("forg: parse mod failed").
+			WithCause(errors.Warning("forg: mod file was not found").
+				WithMeta("file", modFilepath))
+		return
+	}
+	modData, readModErr := files.ReadFile(mod.fsys, modFilepath)
+	if readModErr != nil {
+		err = errors.Warning("forg: parse mod failed").
+			WithCause(errors.Warning("forg: read mod file failed").
+				WithCause(readModErr).
+				WithMeta("file", modFilepath))
+		return
+	}
+	mf, parseModErr := modfile.Parse(modFilepath, modData, nil)
+	if parseModErr != nil {
+		err = errors.Warning("forg: parse mod failed").
+			WithCause(errors.Warning("forg: parse mod file failed").WithCause(parseModErr).WithMeta("file", modFilepath))
+		return
+	}
+	return
+}
+
 func (mod *Module) Services() (services Services, err error) {
 	mod.locker.Lock()
 	defer mod.locker.Unlock()
-	if mod.services != nil {
+	if mod.services != nil && !mod.rescan {
 		services = make([]*Service, 0, 1)
 		for _, service := range mod.services {
 			services = append(services, service)
@@ -291,8 +515,9 @@ func (mod *Module) Services() (services Services, err error) {
 		sort.Sort(services)
 		return
 	}
-	servicesDir := filepath.ToSlash(filepath.Join(mod.Dir, "modules"))
-	entries, readServicesDirErr := os.ReadDir(servicesDir)
+	modulesDir := mod.Config().Modules
+	servicesDir := filepath.ToSlash(filepath.Join(mod.Dir, modulesDir))
+	entries, readServicesDirErr := files.ReadDir(mod.fsys, servicesDir)
 	if readServicesDirErr != nil {
 		err = errors.Warning("read services dir failed").WithCause(readServicesDirErr).WithMeta("dir", servicesDir)
 		return
@@ -304,12 +529,15 @@ func (mod *Module) Services() (services Services, err error) {
 		if !entry.IsDir() {
 			continue
 		}
-		path := filepath.ToSlash(filepath.Join(mod.Path, "modules", entry.Name()))
-		docFilename := filepath.ToSlash(filepath.Join(mod.Dir, "modules", entry.Name(), "doc.go"))
-		if !files.ExistFile(docFilename) {
+		path := filepath.ToSlash(filepath.Join(mod.Path, modulesDir, entry.Name()))
+		docFilename := filepath.ToSlash(filepath.Join(mod.Dir, modulesDir, entry.Name(), "doc.go"))
+		if !files.Exist(mod.fsys, docFilename) {
+			continue
+		}
+		if mod.loadedService(path) {
 			continue
 		}
-		service, loaded, loadErr := tryLoadService(mod, path)
+		service, loaded, loadErr := mod.loadService(path)
 		if loadErr != nil {
 			err = errors.Warning("load service failed").WithCause(loadErr).WithMeta("file", docFilename)
 			return
@@ -327,6 +555,7 @@ func (mod *Module) Services() (services Services, err error) {
 		}
 		mod.services[service.Name] = service
 	}
+	mod.rescan = false
 	services = make([]*Service, 0, 1)
 	for _, service := range mod.services {
 		services = append(services, service)
@@ -335,14 +564,30 @@ func (mod *Module) Services() (services Services, err error) {
 	return
 }
 
+func (mod *Module) loadedService(path string) (ok bool) {
+	for _, service := range mod.services {
+		if service.Path == path {
+			ok = true
+			return
+		}
+	}
+	return
+}
+
+func inPackage(path string, pkg string) (ok bool) {
+	ok = path == pkg || strings.HasPrefix(path, pkg+"/")
+	return
+}
+
 func (mod *Module) findModuleByPath(ctx context.Context, path string) (v *Module, has bool, err error) {
 	if ctx.Err() != nil {
 		err = errors.Warning("forg: find module by path failed").
 			WithCause(ctx.Err())
 		return
 	}
-	if mod.Requires != nil {
-		for _, require := range mod.Requires {
+	requires, _ := mod.requirements()
+	if requires != nil {
+		for _, require := range requires {
 			if path == require.Path || strings.HasPrefix(path, require.Path+"/") {
 				parseErr := require.parse(ctx, mod)
 				if parseErr != nil {
@@ -350,6 +595,15 @@ func (mod *Module) findModuleByPath(ctx context.Context, path string) (v *Module
 						WithCause(parseErr)
 					return
 				}
+				if require.selected {
+					// requires of selected module are its siblings in build list, the longest path is matched first.
+					if require.Replace != nil {
+						require = require.Replace
+					}
+					v = require
+					has = true
+					return
+				}
 				if require.Replace != nil {
 					require = require.Replace
 				}
@@ -387,7 +641,8 @@ func (mod *Module) ParseType(ctx context.Context, path string, name string) (typ
 			WithCause(errors.Warning("forg: module of type was not found"))
 		return
 	}
-	// spec
+	// spec, the generation is kept before it is read
+	ctx, _ = typeModule.types.withGeneration(ctx)
 	spec, specImports, genDoc, findSpecErr := typeModule.sources.FindTypeSpec(path, name)
 	if findSpecErr != nil {
 		err = errors.Warning("forg: mod parse type failed").
@@ -438,7 +693,11 @@ func (mod *Module) String() (s string) {
 	buf := bytes.NewBuffer([]byte{})
 	_, _ = buf.WriteString(fmt.Sprintf("path: %s\n", mod.Path))
 	_, _ = buf.WriteString(fmt.Sprintf("version: %s\n", mod.Version))
-	for _, require := range mod.Requires {
+	requires, work := mod.requirements()
+	if work != nil {
+		_, _ = buf.WriteString(fmt.Sprintf("work: %s\n", work.Filename))
+	}
+	for _, require := range requires {
 		_, _ = buf.WriteString(fmt.Sprintf("requre: %s@%s", require.Path, require.Version))
 		if require.Replace != nil {
 			_, _ = buf.WriteString(fmt.Sprintf("=> %s", require.Replace.Path))
@@ -446,6 +705,9 @@ func (mod *Module) String() (s string) {
 				_, _ = buf.WriteString(fmt.Sprintf("@%s", require.Replace.Version))
 			}
 		}
+		if require.Retracted != "" {
+			_, _ = buf.WriteString(fmt.Sprintf(" (retracted: %s)", require.Retracted))
+		}
 		_, _ = buf.WriteString("\n")
 	}
 	services, servicesErr := mod.Services()
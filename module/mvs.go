@@ -0,0 +1,334 @@
+package module
+
+import (
+	"github.com/aacfactory/errors"
+	"github.com/aacfactory/forg/files"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	// pruningGoVersion is the go version since which requirements of dependencies are pruned.
+	pruningGoVersion = "v1.17"
+)
+
+// buildList returns requires of main module, they are the modules which are selected by go command.
+// In vendor mode, they are read from vendor/modules.txt, otherwise they are computed by minimal version selection.
+func (mod *Module) buildList(mf *modfile.File) (requires Requires, err error) {
+	if mod.Work == nil {
+		vendored, vendorErr := vendorEnabled(mod.fsys, mod.Dir)
+		if vendorErr != nil {
+			err = vendorErr
+			return
+		}
+		if vendored {
+			requires, err = vendorRequires(mod.fsys, mod.Dir)
+			return
+		}
+	}
+	graph := newMVSGraph(mod, mf)
+	roots := make([]module.Version, 0, len(mf.Require))
+	for _, require := range mf.Require {
+		roots = append(roots, require.Mod)
+	}
+	if mod.Work != nil {
+		// requires of modules in workspace are roots too
+		for _, use := range mod.Work.Uses {
+			if use.Dir == mod.Dir {
+				continue
+			}
+			useFile, useErr := readModFile(mod.fsys, filepath.Join(use.Dir, "go.mod"))
+			if useErr != nil {
+				err = useErr
+				return
+			}
+			for _, require := range useFile.Require {
+				roots = append(roots, require.Mod)
+			}
+		}
+	}
+	for _, root := range roots {
+		err = graph.add(root, true)
+		if err != nil {
+			return
+		}
+	}
+	requires, err = graph.requires()
+	return
+}
+
+type mvsGraph struct {
+	main     *Module
+	pruning  bool
+	excludes map[module.Version]bool
+	replaces map[module.Version]module.Version
+	selected map[string]string
+	loaded   map[module.Version]bool
+}
+
+func newMVSGraph(main *Module, mf *modfile.File) (graph *mvsGraph) {
+	graph = &mvsGraph{
+		main:     main,
+		pruning:  mf.Go != nil && semver.Compare("v"+mf.Go.Version, pruningGoVersion) >= 0,
+		excludes: make(map[module.Version]bool),
+		replaces: make(map[module.Version]module.Version),
+		selected: make(map[string]string),
+		loaded:   make(map[module.Version]bool),
+	}
+	for _, exclude := range mf.Exclude {
+		graph.excludes[exclude.Mod] = true
+	}
+	for _, replace := range mf.Replace {
+		graph.replaces[replace.Old] = graph.replacement(main.Dir, replace.New)
+	}
+	if main.Work != nil {
+		// replaces of workspace override replaces of main module which have same module path
+		for _, replace := range main.Work.Replaces {
+			for old := range graph.replaces {
+				if old.Path == replace.Path {
+					delete(graph.replaces, old)
+				}
+			}
+		}
+		for _, replace := range main.Work.Replaces {
+			if replace.Replace == nil {
+				continue
+			}
+			target := module.Version{Path: replace.Replace.Dir, Version: ""}
+			if replace.Replace.Version != "" {
+				target = module.Version{Path: replace.Replace.Path, Version: replace.Replace.Version}
+			}
+			graph.replaces[module.Version{Path: replace.Path, Version: replace.Version}] = target
+		}
+	}
+	return
+}
+
+// replacement makes local path of replacement absolute.
+func (graph *mvsGraph) replacement(dir string, v module.Version) (r module.Version) {
+	r = v
+	if r.Version == "" && !filepath.IsAbs(r.Path) {
+		r.Path = filepath.ToSlash(filepath.Join(dir, filepath.FromSlash(r.Path)))
+	}
+	return
+}
+
+// replaced returns the replacement of module version, a version of replacement is empty when it is a local dir.
+func (graph *mvsGraph) replaced(v module.Version) (r module.Version, has bool) {
+	r, has = graph.replaces[v]
+	if has {
+		return
+	}
+	r, has = graph.replaces[module.Version{Path: v.Path, Version: ""}]
+	return
+}
+
+// add selects the version, and then loads its requirements when load is true.
+// Since go 1.16, requirements on excluded versions are ignored.
+func (graph *mvsGraph) add(v module.Version, load bool) (err error) {
+	if graph.excludes[v] || v.Path == graph.main.Path {
+		return
+	}
+	if current, has := graph.selected[v.Path]; !has || semver.Compare(v.Version, current) > 0 {
+		graph.selected[v.Path] = v.Version
+	}
+	if !load || graph.loaded[v] {
+		return
+	}
+	graph.loaded[v] = true
+	mf, readErr := graph.modFile(v)
+	if readErr != nil {
+		err = readErr
+		return
+	}
+	if mf == nil {
+		return
+	}
+	// requirements of a pruned module are in graph, but their go.mod files are not loaded.
+	pruned := graph.pruning && mf.Go != nil && semver.Compare("v"+mf.Go.Version, pruningGoVersion) >= 0
+	for _, require := range mf.Require {
+		err = graph.add(require.Mod, !pruned)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+// modFile reads go.mod of module version from replacement or module cache, it is nil when it was not downloaded.
+func (graph *mvsGraph) modFile(v module.Version) (mf *modfile.File, err error) {
+	if graph.main.Work != nil {
+		if use, used := graph.main.Work.Use(v.Path); used {
+			mf, err = readModFile(graph.main.fsys, filepath.Join(use.Dir, "go.mod"))
+			return
+		}
+	}
+	if r, replaced := graph.replaced(v); replaced {
+		if r.Version == "" {
+			mf, err = readModFile(graph.main.fsys, filepath.Join(r.Path, "go.mod"))
+			return
+		}
+		v = r
+	}
+	filename, filenameErr := cachedModFilename(v)
+	if filenameErr != nil {
+		err = filenameErr
+		return
+	}
+	if !files.Exist(graph.main.fsys, filename) {
+		dir, dirErr := ModuleCacheDir(v.Path, v.Version)
+		if dirErr != nil {
+			err = dirErr
+			return
+		}
+		filename = filepath.ToSlash(filepath.Join(dir, "go.mod"))
+		if !files.Exist(graph.main.fsys, filename) {
+			return
+		}
+	}
+	mf, err = readModFile(graph.main.fsys, filename)
+	return
+}
+
+func (graph *mvsGraph) requires() (requires Requires, err error) {
+	requires = make(Requires, 0, len(graph.selected))
+	for path, version := range graph.selected {
+		v := module.Version{Path: path, Version: version}
+		if graph.main.Work != nil {
+			if use, used := graph.main.Work.Use(path); used {
+				requires = append(requires, use)
+				continue
+			}
+		}
+		dir, dirErr := ModuleCacheDir(path, version)
+		if dirErr != nil {
+			err = dirErr
+			return
+		}
+		require := &Module{
+			Dir:       dir,
+			Path:      path,
+			Version:   version,
+			Requires:  nil,
+			Work:      nil,
+			Replace:   nil,
+			Retracted: "",
+			locker:    &sync.Mutex{},
+			parsed:    false,
+			services:  nil,
+			types:     nil,
+			selected:  true,
+		}
+		if r, replaced := graph.replaced(v); replaced {
+			// path of replacement must be same as the old one, so packages are still imported by the old path.
+			replaceDir := r.Path
+			if r.Version != "" {
+				replaceDir, err = ModuleCacheDir(r.Path, r.Version)
+				if err != nil {
+					return
+				}
+			}
+			require.Replace = &Module{
+				Dir:       filepath.ToSlash(replaceDir),
+				Path:      path,
+				Version:   r.Version,
+				Requires:  nil,
+				Work:      nil,
+				Replace:   nil,
+				Retracted: "",
+				locker:    &sync.Mutex{},
+				parsed:    false,
+				services:  nil,
+				types:     nil,
+				selected:  true,
+			}
+		} else {
+			require.Retracted = retracted(graph.main.fsys, v)
+		}
+		requires = append(requires, require)
+	}
+	sort.Sort(sort.Reverse(requires))
+	return
+}
+
+// retracted returns the rationale when the version is retracted by the latest go.mod in module cache.
+// Retracted versions are still selected like go command does, so it is only reported.
+func retracted(fsys fs.FS, v module.Version) (rationale string) {
+	filename, filenameErr := cachedModFilename(v)
+	if filenameErr != nil {
+		return
+	}
+	entries, readErr := files.ReadDir(fsys, filepath.Dir(filename))
+	if readErr != nil {
+		return
+	}
+	latest := ""
+	for _, entry := range entries {
+		name, isMod := strings.CutSuffix(entry.Name(), ".mod")
+		if !isMod {
+			continue
+		}
+		version, unescapeErr := module.UnescapeVersion(name)
+		if unescapeErr != nil || !semver.IsValid(version) {
+			continue
+		}
+		if latest == "" || semver.Compare(version, latest) > 0 {
+			latest = version
+		}
+	}
+	if latest == "" {
+		return
+	}
+	latestFilename, _ := cachedModFilename(module.Version{Path: v.Path, Version: latest})
+	mf, mfErr := readModFile(fsys, latestFilename)
+	if mfErr != nil {
+		return
+	}
+	for _, retract := range mf.Retract {
+		if semver.Compare(v.Version, retract.Low) >= 0 && semver.Compare(v.Version, retract.High) <= 0 {
+			rationale = retract.Rationale
+			if rationale == "" {
+				rationale = "retracted by module author"
+			}
+			return
+		}
+	}
+	return
+}
+
+// cachedModFilename returns the go.mod filename of module version in download cache.
+func cachedModFilename(v module.Version) (filename string, err error) {
+	escapedPath, escapePathErr := module.EscapePath(v.Path)
+	if escapePathErr != nil {
+		err = errors.Warning("forg: escape module path failed").WithCause(escapePathErr).WithMeta("path", v.Path)
+		return
+	}
+	escapedVersion, escapeVersionErr := module.EscapeVersion(v.Version)
+	if escapeVersionErr != nil {
+		err = errors.Warning("forg: escape module version failed").WithCause(escapeVersionErr).WithMeta("path", v.Path).WithMeta("version", v.Version)
+		return
+	}
+	filename = filepath.ToSlash(filepath.Join(PKG(), "cache", "download", filepath.FromSlash(escapedPath), "@v", escapedVersion+".mod"))
+	return
+}
+
+func readModFile(fsys fs.FS, filename string) (mf *modfile.File, err error) {
+	filename = filepath.ToSlash(filename)
+	data, readErr := files.ReadFile(fsys, filename)
+	if readErr != nil {
+		err = errors.Warning("forg: read mod file failed").WithCause(readErr).WithMeta("file", filename)
+		return
+	}
+	mf, err = modfile.ParseLax(filename, data, nil)
+	if err != nil {
+		err = errors.Warning("forg: parse mod file failed").WithCause(err).WithMeta("file", filename)
+		return
+	}
+	return
+}
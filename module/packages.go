@@ -0,0 +1,576 @@
+package module
+
+import (
+	"context"
+	"fmt"
+	"github.com/aacfactory/errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	gotypes "go/types"
+	"golang.org/x/tools/go/packages"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	// dependencies are type checked from source too, export data of compiler is not readable between go versions.
+	packagesLoadMode = packages.NeedName | packages.NeedFiles | packages.NeedImports | packages.NeedDeps | packages.NeedTypes | packages.NeedTypesSizes | packages.NeedTypesInfo
+)
+
+// packagesLoader loads packages by go command, and types are built from go/types information instead of ast objects,
+// so dot imports, type aliases and types declared in other files of the package are resolved like the compiler does.
+// Docs of types are read from the files where they are declared.
+type packagesLoader struct {
+	mod      *Module
+	locker   sync.Mutex
+	loaded   bool
+	fset     *token.FileSet
+	packages map[string]*packages.Package
+	files    map[string]*ast.File
+}
+
+func newPackagesLoader(mod *Module) (loader *packagesLoader) {
+	loader = &packagesLoader{
+		mod:      mod,
+		locker:   sync.Mutex{},
+		loaded:   false,
+		fset:     token.NewFileSet(),
+		packages: make(map[string]*packages.Package),
+		files:    make(map[string]*ast.File),
+	}
+	return
+}
+
+func (loader *packagesLoader) refresh() {
+	loader.locker.Lock()
+	loader.loaded = false
+	loader.fset = token.NewFileSet()
+	loader.packages = make(map[string]*packages.Package)
+	loader.files = make(map[string]*ast.File)
+	loader.locker.Unlock()
+}
+
+func (loader *packagesLoader) load(ctx context.Context, patterns ...string) (err error) {
+	work := WorkOff
+	if _, modWork := loader.mod.requirements(); modWork != nil {
+		work = modWork.Filename
+	}
+	flags, env := buildFlags(loader.mod.build)
+	pkgs, loadErr := packages.Load(&packages.Config{
+		Mode:       packagesLoadMode,
+		Context:    ctx,
+		Dir:        loader.mod.Dir,
+		Env:        append(append(os.Environ(), env...), "GOWORK="+work),
+		BuildFlags: flags,
+		Fset:       loader.fset,
+	}, patterns...)
+	if loadErr != nil {
+		err = errors.Warning("forg: load packages failed").WithCause(loadErr).WithMeta("dir", loader.mod.Dir)
+		return
+	}
+	for _, pkg := range pkgs {
+		loader.packages[pkg.PkgPath] = pkg
+	}
+	return
+}
+
+// Package returns the type checked package, services are loaded by one go command at first.
+// Errors of package are ignored, they are reported when the wanted object can not be found.
+func (loader *packagesLoader) Package(ctx context.Context, path string) (pkg *packages.Package, err error) {
+	loader.locker.Lock()
+	defer loader.locker.Unlock()
+	if !loader.loaded {
+		err = loader.load(ctx, fmt.Sprintf("./%s/...", filepath.ToSlash(loader.mod.Config().Modules)))
+		if err != nil {
+			return
+		}
+		loader.loaded = true
+	}
+	has := false
+	pkg, has = loader.packages[path]
+	if !has {
+		err = loader.load(ctx, path)
+		if err != nil {
+			return
+		}
+		pkg, has = loader.packages[path]
+	}
+	if !has || pkg.Types == nil {
+		err = errors.Warning("forg: package was not found").WithMeta("path", path)
+		return
+	}
+	return
+}
+
+func (loader *packagesLoader) Signature(ctx context.Context, path string, ident string) (sig *gotypes.Signature, err error) {
+	pkg, pkgErr := loader.Package(ctx, path)
+	if pkgErr != nil {
+		err = pkgErr
+		return
+	}
+	fn, isFunc := pkg.Types.Scope().Lookup(ident).(*gotypes.Func)
+	if !isFunc {
+		err = errors.Warning("forg: func was not found").WithMeta("path", path).WithMeta("func", ident)
+		if len(pkg.Errors) > 0 {
+			err = err.(errors.CodeError).WithCause(pkg.Errors[0])
+		}
+		return
+	}
+	sig = fn.Type().(*gotypes.Signature)
+	return
+}
+
+// Doc returns the doc of type and docs of its fields, the doc of type falls back to the doc of declaration.
+func (loader *packagesLoader) Doc(obj *gotypes.TypeName) (doc string, fields map[string]string) {
+	fields = make(map[string]string)
+	loader.locker.Lock()
+	defer loader.locker.Unlock()
+	filename := loader.fset.Position(obj.Pos()).Filename
+	if filename == "" {
+		return
+	}
+	file, has := loader.files[filename]
+	if !has {
+		file, _ = parser.ParseFile(token.NewFileSet(), filename, nil, parser.ParseComments)
+		loader.files[filename] = file
+	}
+	if file == nil {
+		return
+	}
+	for _, decl := range file.Decls {
+		genDecl, isGenDecl := decl.(*ast.GenDecl)
+		if !isGenDecl || genDecl.Tok != token.TYPE {
+			continue
+		}
+		for _, s := range genDecl.Specs {
+			spec := s.(*ast.TypeSpec)
+			if spec.Name.Name != obj.Name() {
+				continue
+			}
+			if spec.Doc != nil && spec.Doc.Text() != "" {
+				doc = spec.Doc.Text()
+			} else if genDecl.Doc != nil {
+				doc = genDecl.Doc.Text()
+			}
+			if st, isStruct := spec.Type.(*ast.StructType); isStruct && st.Fields != nil {
+				for _, field := range st.Fields.List {
+					if field.Doc == nil {
+						continue
+					}
+					for _, name := range field.Names {
+						fields[name.Name] = field.Doc.Text()
+					}
+				}
+			}
+			return
+		}
+	}
+	return
+}
+
+func isNamedType(t gotypes.Type, path string, name string) (ok bool) {
+	named, isNamed := unalias(t).(*gotypes.Named)
+	if !isNamed || named.Obj().Pkg() == nil {
+		return
+	}
+	ok = named.Obj().Pkg().Path() == path && named.Obj().Name() == name
+	return
+}
+
+func (types *Types) parseGoType(ctx context.Context, t gotypes.Type) (typ *Type, err error) {
+	switch tt := unalias(t).(type) {
+	case *gotypes.Basic:
+		typ, err = parseGoBasic(tt)
+		break
+	case *gotypes.Named:
+		typ, err = types.parseGoNamed(ctx, tt)
+		break
+	case *gotypes.Pointer:
+		element, elementErr := types.parseGoType(ctx, tt.Elem())
+		if elementErr != nil {
+			err = elementErr
+			break
+		}
+		typ = &Type{
+			Kind:        PointerKind,
+			Path:        "",
+			Name:        "",
+			Annotations: nil,
+			Paradigms:   nil,
+			Tags:        nil,
+			Elements:    []*Type{element},
+		}
+		break
+	case *gotypes.Slice:
+		typ, err = types.parseGoArray(ctx, tt.Elem())
+		break
+	case *gotypes.Array:
+		typ, err = types.parseGoArray(ctx, tt.Elem())
+		break
+	case *gotypes.Map:
+		typ, err = types.parseGoMap(ctx, tt)
+		break
+	case *gotypes.Interface:
+		typ = AnyType
+		break
+	case *gotypes.TypeParam:
+		elements, constraintErr := types.parseGoConstraint(ctx, tt)
+		if constraintErr != nil {
+			err = constraintErr
+			break
+		}
+		typ = &Type{
+			Kind:        ParadigmElementKind,
+			Path:        "",
+			Name:        tt.Obj().Name(),
+			Annotations: nil,
+			Paradigms:   nil,
+			Tags:        nil,
+			Elements:    elements,
+		}
+		break
+	default:
+		err = errors.Warning("forg: unsupported field type").WithMeta("type", t.String())
+		break
+	}
+	return
+}
+
+func parseGoBasic(basic *gotypes.Basic) (typ *Type, err error) {
+	switch basic.Kind() {
+	case gotypes.Bool, gotypes.String,
+		gotypes.Int, gotypes.Int8, gotypes.Int16, gotypes.Int32, gotypes.Int64,
+		gotypes.Uint, gotypes.Uint8, gotypes.Uint16, gotypes.Uint32, gotypes.Uint64,
+		gotypes.Float32, gotypes.Float64, gotypes.Complex64, gotypes.Complex128:
+		typ = &Type{
+			Kind:        BasicKind,
+			Path:        "",
+			Name:        basic.Name(),
+			Annotations: Annotations{},
+			Paradigms:   make([]*TypeParadigm, 0, 1),
+			Elements:    make([]*Type, 0, 1),
+		}
+		break
+	default:
+		err = errors.Warning("forg: unsupported basic type").WithMeta("type", basic.Name())
+		break
+	}
+	return
+}
+
+func (types *Types) parseGoArray(ctx context.Context, elem gotypes.Type) (typ *Type, err error) {
+	element, elementErr := types.parseGoType(ctx, elem)
+	if elementErr != nil {
+		err = elementErr
+		return
+	}
+	typ = &Type{
+		Kind:        ArrayKind,
+		Path:        "",
+		Name:        "",
+		Annotations: nil,
+		Paradigms:   nil,
+		Tags:        nil,
+		Elements:    []*Type{element},
+	}
+	return
+}
+
+func (types *Types) parseGoMap(ctx context.Context, m *gotypes.Map) (typ *Type, err error) {
+	key, keyErr := types.parseGoType(ctx, m.Key())
+	if keyErr != nil {
+		err = keyErr
+		return
+	}
+	if _, basic := key.Basic(); !basic {
+		err = errors.Warning("forg: key kind of map kind field must be basic")
+		return
+	}
+	value, valueErr := types.parseGoType(ctx, m.Elem())
+	if valueErr != nil {
+		err = valueErr
+		return
+	}
+	typ = &Type{
+		Kind:        MapKind,
+		Path:        "",
+		Name:        "",
+		Annotations: nil,
+		Paradigms:   nil,
+		Tags:        nil,
+		Elements:    []*Type{key, value},
+	}
+	return
+}
+
+func (types *Types) parseGoConstraint(ctx context.Context, param *gotypes.TypeParam) (elements []*Type, err error) {
+	elements = make([]*Type, 0, 1)
+	constraint := param.Constraint()
+	if named, isNamed := constraint.(*gotypes.Named); isNamed {
+		if named.Obj().Pkg() == nil {
+			// comparable
+			elements = append(elements, AnyType)
+			return
+		}
+		element, elementErr := types.parseGoNamed(ctx, named)
+		if elementErr != nil {
+			err = elementErr
+			return
+		}
+		elements = append(elements, element)
+		return
+	}
+	iface, isInterface := constraint.Underlying().(*gotypes.Interface)
+	if !isInterface || iface.NumEmbeddeds() != 1 {
+		elements = append(elements, AnyType)
+		return
+	}
+	embedded := iface.EmbeddedType(0)
+	union, isUnion := embedded.(*gotypes.Union)
+	if !isUnion {
+		element, elementErr := types.parseGoType(ctx, embedded)
+		if elementErr != nil {
+			err = elementErr
+			return
+		}
+		elements = append(elements, element)
+		return
+	}
+	for i := 0; i < union.Len(); i++ {
+		element, elementErr := types.parseGoType(ctx, union.Term(i).Type())
+		if elementErr != nil {
+			err = elementErr
+			return
+		}
+		elements = append(elements, element)
+	}
+	return
+}
+
+func (types *Types) parseGoParadigms(ctx context.Context, params *gotypes.TypeParamList) (paradigms []*TypeParadigm, err error) {
+	paradigms = make([]*TypeParadigm, 0, 1)
+	for i := 0; i < params.Len(); i++ {
+		param := params.At(i)
+		elements, constraintErr := types.parseGoConstraint(ctx, param)
+		if constraintErr != nil {
+			err = errors.Warning("forg: parse paradigm failed").WithMeta("name", param.Obj().Name()).WithCause(constraintErr)
+			return
+		}
+		paradigms = append(paradigms, &TypeParadigm{
+			Name:  param.Obj().Name(),
+			Types: elements,
+		})
+	}
+	return
+}
+
+func (types *Types) parseGoNamed(ctx context.Context, named *gotypes.Named) (typ *Type, err error) {
+	obj := named.Obj()
+	if obj.Pkg() == nil {
+		err = errors.Warning("forg: unsupported field type").WithMeta("type", obj.Name())
+		return
+	}
+	path := obj.Pkg().Path()
+	name := obj.Name()
+	builtin, isBuiltin := tryGetBuiltinType(path, name)
+	if isBuiltin {
+		typ = builtin
+		return
+	}
+	// instance of paradigm type
+	if args := named.TypeArgs(); args != nil && args.Len() > 0 {
+		origin, originErr := types.parseGoNamed(ctx, named.Origin())
+		if originErr != nil {
+			err = originErr
+			return
+		}
+		if origin.Paradigms == nil || len(origin.Paradigms) != args.Len() {
+			err = errors.Warning("forg: parse paradigm type instance failed").
+				WithMeta("path", path).WithMeta("name", name).
+				WithCause(errors.Warning("forg: invalid paradigms in origin type"))
+			return
+		}
+		paradigms := make([]*TypeParadigm, 0, args.Len())
+		for i := 0; i < args.Len(); i++ {
+			arg, argErr := types.parseGoType(ctx, args.At(i))
+			if argErr != nil {
+				err = argErr
+				return
+			}
+			paradigms = append(paradigms, &TypeParadigm{
+				Name:  origin.Paradigms[i].Name,
+				Types: []*Type{arg},
+			})
+		}
+		typ = &Type{
+			Kind:            ParadigmKind,
+			Path:            "",
+			Name:            "",
+			Annotations:     nil,
+			Paradigms:       paradigms,
+			Tags:            nil,
+			Elements:        []*Type{origin},
+			ParadigmsPacked: nil,
+		}
+		err = typ.packParadigms(ctx)
+		return
+	}
+
+	key := formatTypeKey(path, name)
+	if ctx.Value(key) != nil {
+		typ = &Type{
+			Kind:        ReferenceKind,
+			Path:        path,
+			Name:        name,
+			Annotations: nil,
+			Paradigms:   nil,
+			Tags:        nil,
+			Elements:    nil,
+		}
+		return
+	}
+	ctx, generation := types.withGeneration(ctx)
+	result, doErr, _ := types.group.Do(types.flight(key, generation), func() (v interface{}, err error) {
+		stored, loaded := types.values.Load(key)
+		if loaded {
+			v = stored.(*Type)
+			return
+		}
+		ctx = context.WithValue(ctx, key, "processing")
+		doc, fieldDocs := types.packages.Doc(obj)
+		annotations, parseAnnotationsErr := ParseAnnotations(doc)
+		if parseAnnotationsErr != nil {
+			err = errors.Warning("forg: parse named type failed").
+				WithMeta("path", path).WithMeta("name", name).
+				WithCause(parseAnnotationsErr)
+			return
+		}
+		var result *Type
+		switch underlying := named.Underlying().(type) {
+		case *gotypes.Struct:
+			result, err = types.parseGoStruct(ctx, underlying, fieldDocs)
+			break
+		case *gotypes.Interface:
+			result = &Type{
+				Kind:        InterfaceKind,
+				Path:        "",
+				Name:        "",
+				Annotations: nil,
+				Paradigms:   nil,
+				Tags:        nil,
+				Elements:    nil,
+			}
+			break
+		case *gotypes.Basic:
+			basic, basicErr := parseGoBasic(underlying)
+			if basicErr != nil {
+				err = basicErr
+				break
+			}
+			result = &Type{
+				Kind:        IdentKind,
+				Path:        "",
+				Name:        "",
+				Annotations: nil,
+				Paradigms:   nil,
+				Tags:        nil,
+				Elements:    []*Type{basic},
+			}
+			break
+		case *gotypes.Slice, *gotypes.Array, *gotypes.Map:
+			result, err = types.parseGoType(ctx, underlying)
+			break
+		default:
+			err = errors.Warning("forg: unsupported type spec").WithMeta("type", underlying.String())
+			break
+		}
+		if err != nil {
+			err = errors.Warning("forg: parse named type failed").
+				WithMeta("path", path).WithMeta("name", name).
+				WithCause(err)
+			return
+		}
+		result.Path = path
+		result.Name = name
+		result.Annotations = annotations
+		if params := named.TypeParams(); params != nil && params.Len() > 0 {
+			result.Paradigms, err = types.parseGoParadigms(ctx, params)
+			if err != nil {
+				err = errors.Warning("forg: parse named type failed").
+					WithMeta("path", path).WithMeta("name", name).
+					WithCause(err)
+				return
+			}
+		}
+		types.store(key, result, generation)
+		v = result
+		return
+	})
+	if doErr != nil {
+		err = doErr
+		return
+	}
+	typ = result.(*Type)
+	return
+}
+
+func (types *Types) parseGoStruct(ctx context.Context, st *gotypes.Struct, docs map[string]string) (typ *Type, err error) {
+	typ = &Type{
+		Kind:        StructKind,
+		Path:        "",
+		Name:        "",
+		Annotations: nil,
+		Paradigms:   nil,
+		Tags:        nil,
+		Elements:    nil,
+	}
+	if st.NumFields() == 0 {
+		return
+	}
+	typ.Elements = make([]*Type, 0, 1)
+	for i := 0; i < st.NumFields(); i++ {
+		field := st.Field(i)
+		if !field.Embedded() && !field.Exported() {
+			continue
+		}
+		ft := &Type{
+			Kind:        StructFieldKind,
+			Path:        "",
+			Name:        "",
+			Annotations: nil,
+			Paradigms:   nil,
+			Tags:        nil,
+			Elements:    nil,
+		}
+		if !field.Embedded() {
+			ft.Name = field.Name()
+			if tag := st.Tag(i); tag != "" {
+				ft.Tags = parseFieldTag(tag)
+			}
+			if doc := docs[field.Name()]; doc != "" {
+				fieldAnnotations, parseFieldAnnotationsErr := ParseAnnotations(doc)
+				if parseFieldAnnotationsErr != nil {
+					err = errors.Warning("forg: parse struct type failed").
+						WithCause(parseFieldAnnotationsErr).
+						WithMeta("field_no", fmt.Sprintf("%d", i)).
+						WithMeta("field", ft.Name)
+					return
+				}
+				ft.Annotations = fieldAnnotations
+			}
+		}
+		element, elementErr := types.parseGoType(ctx, field.Type())
+		if elementErr != nil {
+			err = errors.Warning("forg: parse struct type failed").
+				WithCause(elementErr).
+				WithMeta("field_no", fmt.Sprintf("%d", i)).
+				WithMeta("field", field.Name())
+			return
+		}
+		ft.Elements = []*Type{element}
+		typ.Elements = append(typ.Elements, ft)
+	}
+	return
+}
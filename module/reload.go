@@ -0,0 +1,240 @@
+package module
+
+import (
+	"context"
+	"github.com/aacfactory/errors"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Invalidate drops cached sources, services and types of packages, so they will be read again by next Services and ParseType.
+// A path is an import path of package, or an absolute path of a dir or a go file in the module or in a local module of workspace and replaces.
+// A package path invalidates the package and its sub packages, types which refer to them and services which import them,
+// others are kept. It is safe to call while other goroutines are reading the module.
+func (mod *Module) Invalidate(paths ...string) {
+	if len(paths) == 0 {
+		return
+	}
+	if mod.Replace != nil {
+		mod.Replace.Invalidate(paths...)
+		return
+	}
+	mod.locker.Lock()
+	parsed := mod.parsed
+	mod.locker.Unlock()
+	if !parsed {
+		return
+	}
+	loaded := mod.loadedModules()
+	pkgs := make(map[string]bool)
+	for _, path := range paths {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		if filepath.IsAbs(path) {
+			pkg, has := packageOfFile(loaded, path)
+			if !has {
+				continue
+			}
+			path = pkg
+		}
+		pkgs[path] = true
+	}
+	if len(pkgs) == 0 {
+		return
+	}
+	for _, loadedModule := range loaded {
+		for pkg := range pkgs {
+			if inPackage(pkg, loadedModule.Path) || inPackage(loadedModule.Path, pkg) {
+				loadedModule.sources.refresh(pkg)
+			}
+		}
+	}
+	// types which refer to invalidated packages are dropped, and then packages of them are affected too.
+	affected := make(map[string]bool)
+	for pkg := range pkgs {
+		affected[pkg] = true
+	}
+	mod.types.invalidate(func(typ *Type) (ok bool) {
+		refers := make(map[string]bool)
+		collectTypePaths(typ, refers, make(map[*Type]bool))
+		for refer := range refers {
+			if inPackages(refer, pkgs) {
+				affected[typ.Path] = true
+				ok = true
+				return
+			}
+		}
+		return
+	})
+	mod.locker.Lock()
+	for name, service := range mod.services {
+		if inPackages(service.Path, affected) {
+			delete(mod.services, name)
+			continue
+		}
+		for _, imported := range service.Imports {
+			if inPackages(imported.Path, affected) {
+				delete(mod.services, name)
+				break
+			}
+		}
+	}
+	mod.rescan = true
+	mod.locker.Unlock()
+	if mod.types.packages != nil {
+		mod.types.packages.refresh()
+	}
+	if mod.cache != nil {
+		mod.cache.reset()
+	}
+	return
+}
+
+// Reload reads go.mod and go.work again, and drops all sources, services and types,
+// so the module is same as a new one which is parsed, but the module path can not be changed.
+// It is safe to call while other goroutines are reading the module, they get results of old or new requires.
+func (mod *Module) Reload(ctx context.Context) (err error) {
+	if ctx.Err() != nil {
+		err = errors.Warning("forg: reload mod failed").WithCause(ctx.Err())
+		return
+	}
+	mod.locker.Lock()
+	parsed := mod.parsed
+	mod.locker.Unlock()
+	if !parsed {
+		err = mod.Parse(ctx)
+		return
+	}
+	mod.locker.Lock()
+	defer mod.locker.Unlock()
+	mf, parseModErr := mod.parseModFile()
+	if parseModErr != nil {
+		err = errors.Warning("forg: reload mod failed").WithCause(parseModErr)
+		return
+	}
+	if mf.Module.Mod.Path != mod.Path {
+		err = errors.Warning("forg: reload mod failed").
+			WithCause(errors.Warning("forg: module path was changed, create a new module")).
+			WithMeta("mod", mod.Path).WithMeta("path", mf.Module.Mod.Path)
+		return
+	}
+	_, prevWork := mod.requirements()
+	var work *Work
+	if prevWork != nil {
+		work = &Work{
+			Filename: prevWork.Filename,
+			Uses:     nil,
+			Replaces: nil,
+			parsed:   false,
+			fsys:     mod.fsys,
+		}
+		parseWorkErr := work.Parse()
+		if parseWorkErr != nil {
+			err = errors.Warning("forg: reload mod failed").WithCause(parseWorkErr)
+			return
+		}
+		used := false
+		for i, use := range work.Uses {
+			if use.Dir == mod.Dir {
+				work.Uses[i] = mod
+				used = true
+				break
+			}
+		}
+		if !used {
+			err = errors.Warning("forg: reload mod failed").
+				WithCause(errors.Warning("forg: module is not used by the workspace")).
+				WithMeta("work", work.Filename).WithMeta("dir", mod.Dir)
+			return
+		}
+	}
+	// build list is made with the new workspace, and the old one is restored when it failed.
+	mod.requiresLocker.Lock()
+	mod.Work = work
+	mod.requiresLocker.Unlock()
+	requires, buildListErr := mod.buildList(mf)
+	if buildListErr != nil {
+		mod.requiresLocker.Lock()
+		mod.Work = prevWork
+		mod.requiresLocker.Unlock()
+		err = errors.Warning("forg: reload mod failed").WithCause(buildListErr).WithMeta("mod", mod.Path)
+		return
+	}
+	if requires.Len() > 0 {
+		sort.Sort(sort.Reverse(requires))
+	}
+	dependencies := mod.dependencyHash(work)
+	mod.requiresLocker.Lock()
+	mod.Requires = requires
+	mod.dependencies = dependencies
+	mod.requiresLocker.Unlock()
+	mod.Version = mf.Module.Mod.Version
+
+	mod.sources.reset()
+	mod.types.invalidate(nil)
+	if mod.types.packages != nil {
+		mod.types.packages.refresh()
+	}
+	if mod.cache != nil {
+		mod.cache.reset()
+	}
+	mod.services = nil
+	mod.rescan = false
+	return
+}
+
+// loadedModules returns the module and its parsed requires, replaced requires are their replacements.
+func (mod *Module) loadedModules() (modules []*Module) {
+	modules = []*Module{mod}
+	requires, _ := mod.requirements()
+	for _, require := range requires {
+		if require.Replace != nil {
+			require = require.Replace
+		}
+		require.locker.Lock()
+		parsed := require.parsed
+		require.locker.Unlock()
+		if parsed {
+			modules = append(modules, require)
+		}
+	}
+	return
+}
+
+// packageOfFile returns the import path of the dir or the go file, the module of the longest dir is used.
+func packageOfFile(modules []*Module, path string) (pkg string, has bool) {
+	dir := filepath.Clean(path)
+	if filepath.Ext(dir) == ".go" {
+		dir = filepath.Dir(dir)
+	}
+	matched := ""
+	for _, candidate := range modules {
+		if candidate.Dir == "" || len(candidate.Dir) <= len(matched) {
+			continue
+		}
+		rel, relErr := filepath.Rel(filepath.FromSlash(candidate.Dir), dir)
+		if relErr != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		matched = candidate.Dir
+		pkg = candidate.Path
+		if rel != "." {
+			pkg = pkg + "/" + filepath.ToSlash(rel)
+		}
+		has = true
+	}
+	return
+}
+
+func inPackages(path string, pkgs map[string]bool) (ok bool) {
+	for pkg := range pkgs {
+		if inPackage(path, pkg) {
+			ok = true
+			return
+		}
+	}
+	return
+}
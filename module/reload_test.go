@@ -0,0 +1,192 @@
+package module_test
+
+import (
+	"context"
+	"github.com/aacfactory/forg/module"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func writeFiles(t *testing.T, dir string, contents map[string]string) {
+	for name, content := range contents {
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestModule_Invalidate(t *testing.T) {
+	t.Setenv("GOENV", "off")
+	t.Setenv("GOMODCACHE", t.TempDir())
+	t.Setenv("GOFLAGS", "")
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod":          "module example.com/reload\n\ngo 1.20\n",
+		"tags/tag.go":     "package tags\n\ntype Tag struct {\n\tName string `json:\"name\"`\n}\n",
+		"models/item.go":  "package models\n\nimport \"example.com/reload/tags\"\n\ntype Item struct {\n\tTag tags.Tag `json:\"tag\"`\n}\n",
+		"models/other.go": "package models\n\ntype Other struct {\n\tId string `json:\"id\"`\n}\n",
+	})
+	mod, newErr := module.New(filepath.Join(dir, "go.mod"))
+	if newErr != nil {
+		t.Fatalf("%+v", newErr)
+	}
+	ctx := context.TODO()
+	if err := mod.Parse(ctx); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	for _, name := range []string{"Item", "Other"} {
+		if _, err := mod.ParseType(ctx, "example.com/reload/models", name); err != nil {
+			t.Fatalf("%+v", err)
+		}
+	}
+	writeFiles(t, dir, map[string]string{
+		"tags/tag.go": "package tags\n\ntype Tag struct {\n\tName string `json:\"name\"`\n\tColor string `json:\"color\"`\n}\n",
+	})
+	mod.Invalidate(filepath.Join(dir, "tags", "tag.go"))
+	if _, has := mod.GetType("example.com/reload/tags", "Tag"); has {
+		t.Error("expect tag is dropped")
+	}
+	if _, has := mod.GetType("example.com/reload/models", "Item"); has {
+		t.Error("expect item which refers to tag is dropped")
+	}
+	if _, has := mod.GetType("example.com/reload/models", "Other"); !has {
+		t.Error("expect other is kept")
+	}
+	item, parseErr := mod.ParseType(ctx, "example.com/reload/models", "Item")
+	if parseErr != nil {
+		t.Fatalf("%+v", parseErr)
+	}
+	if tag := item.Elements[0].Elements[0]; len(tag.Elements) != 2 {
+		t.Fatalf("expect changed tag, got %+v", tag.Elements)
+	}
+	// invalidating while others are reading
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				if _, err := mod.ParseType(ctx, "example.com/reload/models", "Item"); err != nil {
+					t.Errorf("%+v", err)
+					return
+				}
+			}
+		}()
+	}
+	for j := 0; j < 20; j++ {
+		mod.Invalidate("example.com/reload/tags")
+		if err := mod.Reload(ctx); err != nil {
+			t.Fatalf("%+v", err)
+		}
+	}
+	wg.Wait()
+}
+
+func TestModule_InvalidateWhileParsing(t *testing.T) {
+	t.Setenv("GOENV", "off")
+	t.Setenv("GOMODCACHE", t.TempDir())
+	t.Setenv("GOFLAGS", "")
+	dir := t.TempDir()
+	tags := []string{
+		"package tags\n\ntype Tag struct {\n\tName string `json:\"name\"`\n}\n",
+		"package tags\n\ntype Tag struct {\n\tName string `json:\"name\"`\n\tColor string `json:\"color\"`\n}\n",
+	}
+	writeFiles(t, dir, map[string]string{
+		"go.mod":         "module example.com/reload\n\ngo 1.20\n",
+		"tags/tag.go":    tags[0],
+		"models/item.go": "package models\n\nimport \"example.com/reload/tags\"\n\ntype Item struct {\n\tTag tags.Tag `json:\"tag\"`\n}\n",
+	})
+	mod, newErr := module.New(filepath.Join(dir, "go.mod"))
+	if newErr != nil {
+		t.Fatalf("%+v", newErr)
+	}
+	ctx := context.TODO()
+	if err := mod.Parse(ctx); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	// parses which began before an invalidation must not put stale types back after it.
+	stop := make(chan struct{})
+	wg := &sync.WaitGroup{}
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				if _, err := mod.ParseType(ctx, "example.com/reload/models", "Item"); err != nil {
+					t.Errorf("%+v", err)
+					return
+				}
+			}
+		}()
+	}
+	for i := 0; i < 500; i++ {
+		version := i % len(tags)
+		filename := filepath.Join(dir, "tags", "tag.go")
+		if err := os.WriteFile(filename+".tmp", []byte(tags[version]), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Rename(filename+".tmp", filename); err != nil {
+			t.Fatal(err)
+		}
+		mod.Invalidate(filename)
+		item, parseErr := mod.ParseType(ctx, "example.com/reload/models", "Item")
+		if parseErr != nil {
+			t.Fatalf("%+v", parseErr)
+		}
+		if tag := item.Elements[0].Elements[0]; len(tag.Elements) != version+1 {
+			t.Fatalf("expect tag of version %d after invalidation, got %d fields", version, len(tag.Elements))
+		}
+	}
+	close(stop)
+	wg.Wait()
+}
+
+func TestModule_Reload(t *testing.T) {
+	t.Setenv("GOENV", "off")
+	t.Setenv("GOMODCACHE", t.TempDir())
+	t.Setenv("GOFLAGS", "")
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod":     "module example.com/reload\n\ngo 1.20\n",
+		"app/app.go": "package app\n",
+		"dep/go.mod": "module example.com/dep\n\ngo 1.20\n",
+		"dep/dep.go": "package dep\n\ntype Page struct {\n\tNo int `json:\"no\"`\n}\n",
+	})
+	mod, newErr := module.New(filepath.Join(dir, "go.mod"))
+	if newErr != nil {
+		t.Fatalf("%+v", newErr)
+	}
+	ctx := context.TODO()
+	if err := mod.Parse(ctx); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if _, err := mod.ParseType(ctx, "example.com/dep", "Page"); err == nil {
+		t.Fatal("expect dep is not required")
+	}
+	writeFiles(t, dir, map[string]string{
+		"go.mod": "module example.com/reload\n\ngo 1.20\n\nrequire example.com/dep v1.0.0\n\nreplace example.com/dep => ./dep\n",
+	})
+	if err := mod.Reload(ctx); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if _, err := mod.ParseType(ctx, "example.com/dep", "Page"); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	writeFiles(t, dir, map[string]string{
+		"go.mod": "module example.com/renamed\n\ngo 1.20\n",
+	})
+	if err := mod.Reload(ctx); err == nil {
+		t.Fatal("expect error of changed module path")
+	}
+}
@@ -0,0 +1,174 @@
+package module
+
+import (
+	"fmt"
+	"github.com/aacfactory/cases"
+	"github.com/aacfactory/errors"
+	"go/ast"
+	"go/token"
+	"strings"
+)
+
+const (
+	paradigmMessage        = "function can not use paradigm"
+	paramsLengthMessage    = "params length must be one or two"
+	contextParamMessage    = "first param must be context.Context"
+	resultsLengthMessage   = "results length must be one or two"
+	codeErrorResultMessage = "the last result must be github.com/aacfactory/errors.CodeError"
+	// codeErrorResultFailure is the message of parse error, it is not changed with the message of lint.
+	codeErrorResultFailure = "the last results must github.com/aacfactory/errors.CodeError"
+)
+
+// problem is a violation of rules of services, functions and components at a position of source.
+// Parsing fails by the failure of first problem, and Lint reports messages of all of them.
+type problem struct {
+	pos     token.Pos
+	message string
+	cause   error
+	failure error
+}
+
+// err returns the error of parsing.
+func (p problem) err() (err error) {
+	err = p.failure
+	return
+}
+
+type problems []problem
+
+func (ps problems) first() (p problem, has bool) {
+	if len(ps) == 0 {
+		return
+	}
+	p = ps[0]
+	has = true
+	return
+}
+
+// servicePackageProblems checks that the package name of doc.go of service is same as the dir name.
+func servicePackageProblems(file *ast.File, dirName string) (ps problems) {
+	if file.Name.Name != dirName {
+		ps = append(ps, problem{
+			pos:     file.Name.Pos(),
+			message: fmt.Sprintf("package name %s must be same as dir name %s", file.Name.Name, dirName),
+			cause:   nil,
+			failure: errors.Warning("pkg must be same as dir name"),
+		})
+	}
+	return
+}
+
+// functionDoc returns the doc of func declaration which is a function of service, methods are not functions.
+func functionDoc(decl *ast.FuncDecl) (doc string, ok bool) {
+	if decl.Doc == nil {
+		return
+	}
+	doc = decl.Doc.Text()
+	ok = strings.Contains(doc, "@fn")
+	return
+}
+
+// functionNameProblems checks that the name of function is unexported and lower camel case.
+func functionNameProblems(decl *ast.FuncDecl) (ps problems) {
+	ident := decl.Name.Name
+	if ast.IsExported(ident) {
+		ps = append(ps, problem{
+			pos:     decl.Name.Pos(),
+			message: "func name must not be exported",
+			cause:   nil,
+			failure: errors.Warning("forg: func name must not be exported"),
+		})
+		return
+	}
+	if _, parseNameErr := cases.LowerCamel().Parse(ident); parseNameErr != nil {
+		ps = append(ps, problem{
+			pos:     decl.Name.Pos(),
+			message: "func name must be lower camel case",
+			cause:   parseNameErr,
+			failure: parseNameErr,
+		})
+	}
+	return
+}
+
+// paradigmProblems checks that the function has no type params.
+func paradigmProblems(decl *ast.FuncDecl) (ps problems) {
+	if decl.Type.TypeParams != nil && len(decl.Type.TypeParams.List) > 0 {
+		ps = append(ps, problem{
+			pos:     decl.Type.TypeParams.Pos(),
+			message: paradigmMessage,
+			cause:   nil,
+			failure: errors.Warning(paradigmMessage),
+		})
+	}
+	return
+}
+
+// signatureProblems checks lengths of params and results, the context param and the CodeError result,
+// types of the param and the result are checked by parseField.
+func (types *Types) signatureProblems(decl *ast.FuncDecl, imports Imports) (ps problems) {
+	params := decl.Type.Params
+	if params == nil || len(params.List) == 0 || len(params.List) > 2 {
+		ps = append(ps, problem{
+			pos:     decl.Type.Params.Pos(),
+			message: paramsLengthMessage,
+			cause:   nil,
+			failure: errors.Warning(paramsLengthMessage),
+		})
+	} else if !types.isContextType(params.List[0].Type, imports) {
+		ps = append(ps, problem{
+			pos:     params.List[0].Type.Pos(),
+			message: contextParamMessage,
+			cause:   nil,
+			failure: errors.Warning(contextParamMessage),
+		})
+	}
+	results := decl.Type.Results
+	if results == nil || len(results.List) == 0 || len(results.List) > 2 {
+		pos := decl.Type.Params.End()
+		if results != nil {
+			pos = results.Pos()
+		}
+		ps = append(ps, problem{
+			pos:     pos,
+			message: resultsLengthMessage,
+			cause:   nil,
+			failure: errors.Warning(resultsLengthMessage),
+		})
+		return
+	}
+	last := results.List[len(results.List)-1]
+	if !types.isCodeErrorType(last.Type, imports) {
+		ps = append(ps, problem{
+			pos:     last.Type.Pos(),
+			message: codeErrorResultMessage,
+			cause:   nil,
+			failure: errors.Warning(codeErrorResultFailure),
+		})
+	}
+	return
+}
+
+// componentDoc returns the doc of type spec, the doc of declaration is used when it has only one spec.
+func componentDoc(decl *ast.GenDecl, spec *ast.TypeSpec) (doc string, ok bool) {
+	if spec.Doc != nil && spec.Doc.Text() != "" {
+		doc = spec.Doc.Text()
+	} else if len(decl.Specs) == 1 && decl.Doc != nil {
+		doc = decl.Doc.Text()
+	}
+	ok = strings.Contains(doc, "@component")
+	return
+}
+
+// componentProblems checks that the component is exported.
+func componentProblems(spec *ast.TypeSpec) (ps problems) {
+	if !ast.IsExported(spec.Name.Name) {
+		ps = append(ps, problem{
+			pos:     spec.Name.Pos(),
+			message: "component name must be exported",
+			cause:   nil,
+			failure: errors.Warning("forg: component name must be exported"),
+		})
+	}
+	return
+}
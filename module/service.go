@@ -37,8 +37,8 @@ func tryLoadService(mod *Module, path string) (service *Service, has bool, err e
 		return
 	}
 	_, pkg := filepath.Split(path)
-	if pkg != f.Name.Name {
-		err = errors.Warning("forg: parse service failed").WithCause(errors.Warning("pkg must be same as dir name")).WithMeta("path", path).WithMeta("file", "doc.go")
+	if violation, violated := servicePackageProblems(f, pkg).first(); violated {
+		err = errors.Warning("forg: parse service failed").WithCause(violation.err()).WithMeta("path", path).WithMeta("file", "doc.go")
 		return
 	}
 
@@ -135,29 +135,19 @@ func (service *Service) loadFunctions() (err error) {
 			if funcDecl.Recv != nil {
 				continue
 			}
-			if funcDecl.Doc == nil {
-				continue
-			}
-			doc := funcDecl.Doc.Text()
-			if !strings.Contains(doc, "@fn") {
+			doc, isFunction := functionDoc(funcDecl)
+			if !isFunction {
 				continue
 			}
 			ident := funcDecl.Name.Name
-			if ast.IsExported(ident) {
-				err = errors.Warning("forg: parse func name failed").
-					WithMeta("file", filename).
-					WithMeta("func", ident).
-					WithCause(errors.Warning("forg: func name must not be exported"))
-				return
-			}
-			nameAtoms, parseNameErr := cases.LowerCamel().Parse(ident)
-			if parseNameErr != nil {
+			if violation, violated := functionNameProblems(funcDecl).first(); violated {
 				err = errors.Warning("forg: parse func name failed").
 					WithMeta("file", filename).
 					WithMeta("func", ident).
-					WithCause(parseNameErr)
+					WithCause(violation.err())
 				return
 			}
+			nameAtoms, _ := cases.LowerCamel().Parse(ident)
 			proxyIdent := cases.Camel().Format(nameAtoms)
 			constIdent := fmt.Sprintf("_%sFn", ident)
 			annotations, parseAnnotationsErr := ParseAnnotations(doc)
@@ -196,7 +186,7 @@ func (service *Service) loadComponents() (err error) {
 		err = errors.Warning("forg: read service components dir failed").WithCause(dirErr).WithMeta("service", service.Path)
 		return
 	}
-	if !files.ExistFile(dir) {
+	if !files.Exist(service.mod.fsys, dir) {
 		return
 	}
 	readErr := service.mod.sources.ReadDir(componentsPath, func(file *ast.File, filename string) (err error) {
@@ -217,23 +207,15 @@ func (service *Service) loadComponents() (err error) {
 				if !tsOk {
 					continue
 				}
-				doc := ""
-				if ts.Doc == nil || ts.Doc.Text() == "" {
-					if len(specs) == 1 && genDecl.Doc != nil && genDecl.Doc.Text() != "" {
-						doc = genDecl.Doc.Text()
-					}
-				} else {
-					doc = ts.Doc.Text()
-				}
-				if !strings.Contains(doc, "@component") {
+				if _, isComponent := componentDoc(genDecl, ts); !isComponent {
 					continue
 				}
 				ident := ts.Name.Name
-				if !ast.IsExported(ident) {
+				if violation, violated := componentProblems(ts).first(); violated {
 					err = errors.Warning("forg: parse component name failed").
 						WithMeta("file", filename).
 						WithMeta("component", ident).
-						WithCause(errors.Warning("forg: component name must be exported"))
+						WithCause(violation.err())
 					return
 				}
 				service.Components = append(service.Components, &Component{
@@ -260,12 +242,13 @@ func (service *Service) mergeImports() {
 		Path:  "github.com/aacfactory/errors",
 		Alias: "",
 	})
+	config := service.mod.Config()
 	importer.Add(&Import{
-		Path:  "github.com/aacfactory/fns/service",
+		Path:  config.Imports.Service,
 		Alias: "",
 	})
 	importer.Add(&Import{
-		Path:  "github.com/aacfactory/fns/service/documents",
+		Path:  config.Imports.Documents,
 		Alias: "",
 	})
 	imports := make([]Imports, 0, 1)
@@ -2,20 +2,31 @@ package module
 
 import (
 	"github.com/aacfactory/errors"
+	"github.com/aacfactory/forg/files"
 	"go/ast"
+	"go/build"
 	"go/parser"
 	"go/token"
-	"os"
+	"io"
+	"io/fs"
 	"path/filepath"
 	"strings"
 	"sync"
 )
 
-func newSource(path string, dir string) *Sources {
+func newSource(path string, dir string, ctx *build.Context, fsys fs.FS) *Sources {
+	if ctx == nil {
+		ctx = newBuildContext("", "", nil)
+	}
+	if fsys == nil {
+		fsys = files.Disk()
+	}
 	return &Sources{
 		locker:  &sync.Mutex{},
 		dir:     dir,
 		path:    path,
+		build:   ctx,
+		fsys:    fsys,
 		readers: make(map[string]*SourceDirReader),
 	}
 }
@@ -24,9 +35,30 @@ type Sources struct {
 	locker  sync.Locker
 	dir     string
 	path    string
+	build   *build.Context
+	fsys    fs.FS
 	readers map[string]*SourceDirReader
 }
 
+// match reports whether the file in dir is built by build constraints, tests are not built.
+// File name suffixes, such as _linux.go, and //go:build lines are matched like go/build.
+func (sources *Sources) match(dir string, name string) (ok bool, err error) {
+	if strings.HasSuffix(name, "_test.go") || filepath.Ext(name) != ".go" {
+		return
+	}
+	// files are opened in the file system of sources instead of disk
+	ctx := *sources.build
+	ctx.OpenFile = func(path string) (io.ReadCloser, error) {
+		return sources.fsys.Open(files.Name(path))
+	}
+	ok, err = ctx.MatchFile(dir, name)
+	if err != nil {
+		err = errors.Warning("forg: match build constraints failed").WithCause(err).WithMeta("dir", dir).WithMeta("file", name)
+		return
+	}
+	return
+}
+
 func (sources *Sources) destinationPath(path string) (v string, err error) {
 	sub, cut := strings.CutPrefix(path, sources.path+"/")
 	if !cut {
@@ -62,7 +94,7 @@ func (sources *Sources) ReadFile(path string, name string) (file *ast.File, file
 		return
 	}
 	filename = filepath.ToSlash(filepath.Join(dir, name))
-	file, err = parser.ParseFile(token.NewFileSet(), filename, nil, parser.AllErrors|parser.ParseComments)
+	file, err = parseSourceFile(sources.fsys, filename)
 	if err != nil {
 		err = errors.Warning("forg: read file failed").WithCause(err).WithMeta("path", path).WithMeta("file", name).WithMeta("mod", sources.path)
 		return
@@ -81,7 +113,7 @@ func (sources *Sources) getReader(path string) (reader *SourceDirReader, err err
 			sources.locker.Unlock()
 			return
 		}
-		entries, readErr := os.ReadDir(dir)
+		entries, readErr := files.ReadDir(sources.fsys, dir)
 		if readErr != nil {
 			err = errors.Warning("forg: get source reader failed").WithCause(readErr).WithMeta("path", path).WithMeta("mod", sources.path)
 			sources.locker.Unlock()
@@ -92,14 +124,24 @@ func (sources *Sources) getReader(path string) (reader *SourceDirReader, err err
 			sources.locker.Unlock()
 			return
 		}
-		files := make([]*SourceFile, 0, len(entries))
+		sourceFiles := make([]*SourceFile, 0, len(entries))
 		for _, entry := range entries {
-			if entry.IsDir() || strings.HasSuffix(entry.Name(), "_test.go") || filepath.Ext(entry.Name()) != ".go" {
+			if entry.IsDir() {
+				continue
+			}
+			matched, matchErr := sources.match(dir, entry.Name())
+			if matchErr != nil {
+				err = errors.Warning("forg: get source reader failed").WithCause(matchErr).WithMeta("path", path).WithMeta("mod", sources.path)
+				sources.locker.Unlock()
+				return
+			}
+			if !matched {
 				continue
 			}
-			files = append(files, &SourceFile{
+			sourceFiles = append(sourceFiles, &SourceFile{
 				locker:   &sync.Mutex{},
 				parsed:   false,
+				fsys:     sources.fsys,
 				filename: filepath.ToSlash(filepath.Join(dir, entry.Name())),
 				file:     nil,
 				err:      nil,
@@ -107,7 +149,7 @@ func (sources *Sources) getReader(path string) (reader *SourceDirReader, err err
 		}
 		reader = &SourceDirReader{
 			locker: &sync.Mutex{},
-			files:  files,
+			files:  sourceFiles,
 		}
 		sources.readers[path] = reader
 	}
@@ -115,6 +157,23 @@ func (sources *Sources) getReader(path string) (reader *SourceDirReader, err err
 	return
 }
 
+func (sources *Sources) refresh(path string) {
+	sources.locker.Lock()
+	for key := range sources.readers {
+		if inPackage(key, path) {
+			delete(sources.readers, key)
+		}
+	}
+	sources.locker.Unlock()
+}
+
+// reset drops all readers, files are read again.
+func (sources *Sources) reset() {
+	sources.locker.Lock()
+	sources.readers = make(map[string]*SourceDirReader)
+	sources.locker.Unlock()
+}
+
 func (sources *Sources) ReadDir(path string, fn func(file *ast.File, filename string) (err error)) (err error) {
 	reader, readerErr := sources.getReader(path)
 	if readerErr != nil {
@@ -222,6 +281,7 @@ func (reader *SourceDirReader) Find(matcher func(file *ast.File) (ok bool)) (fil
 type SourceFile struct {
 	locker   sync.Locker
 	parsed   bool
+	fsys     fs.FS
 	filename string
 	file     *ast.File
 	err      error
@@ -231,7 +291,7 @@ func (sf *SourceFile) File() (file *ast.File, err error) {
 	sf.locker.Lock()
 	defer sf.locker.Unlock()
 	if !sf.parsed {
-		file, err = parser.ParseFile(token.NewFileSet(), sf.filename, nil, parser.AllErrors|parser.ParseComments)
+		file, err = parseSourceFile(sf.fsys, sf.filename)
 		if err != nil {
 			err = errors.Warning("forg: parse source failed").WithCause(err).WithMeta("file", sf.filename)
 			sf.err = err
@@ -245,3 +305,14 @@ func (sf *SourceFile) File() (file *ast.File, err error) {
 	err = sf.err
 	return
 }
+
+// parseSourceFile reads the file in the file system and parses it with comments.
+func parseSourceFile(fsys fs.FS, filename string) (file *ast.File, err error) {
+	src, readErr := files.ReadFile(fsys, filename)
+	if readErr != nil {
+		err = readErr
+		return
+	}
+	file, err = parser.ParseFile(token.NewFileSet(), filename, src, parser.AllErrors|parser.ParseComments)
+	return
+}
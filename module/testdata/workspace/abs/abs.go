@@ -0,0 +1,5 @@
+package abs
+
+type Item struct {
+	Id string `json:"id"`
+}
@@ -0,0 +1,9 @@
+module example.com/app
+
+go 1.20
+
+require (
+	example.com/abs v1.0.0
+	example.com/local v1.0.0
+	example.com/versioned v1.0.0
+)
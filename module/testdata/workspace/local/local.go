@@ -0,0 +1,5 @@
+package local
+
+type Query struct {
+	Page int `json:"page"`
+}
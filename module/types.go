@@ -442,8 +442,77 @@ type TypeScope struct {
 }
 
 type Types struct {
-	values sync.Map
-	group  singleflight.Group
+	values     sync.Map
+	group      singleflight.Group
+	packages   *packagesLoader
+	locker     sync.RWMutex
+	generation uint64
+}
+
+// current returns the generation of values, it is increased by every invalidation.
+func (types *Types) current() (generation uint64) {
+	types.locker.RLock()
+	generation = types.generation
+	types.locker.RUnlock()
+	return
+}
+
+// generationContextKey is the key of the generation of types in context.
+type generationContextKey struct {
+	types *Types
+}
+
+// withGeneration keeps the generation in ctx before sources are read, so nested parses which use them are stored in the same generation.
+func (types *Types) withGeneration(ctx context.Context) (v context.Context, generation uint64) {
+	key := generationContextKey{
+		types: types,
+	}
+	if stored, ok := ctx.Value(key).(uint64); ok {
+		v, generation = ctx, stored
+		return
+	}
+	generation = types.current()
+	v = context.WithValue(ctx, key, generation)
+	return
+}
+
+// flight returns the key of singleflight in the generation, so a parse which began before an invalidation is not shared after it.
+func (types *Types) flight(key string, generation uint64) (v string) {
+	v = fmt.Sprintf("%s@%d", key, generation)
+	return
+}
+
+// store puts the type which was parsed in the generation, it is dropped when types were invalidated after the generation,
+// so a parse which raced with invalidate can not put a stale type back.
+func (types *Types) store(key string, typ *Type, generation uint64) {
+	types.locker.RLock()
+	if types.generation == generation {
+		types.values.Store(key, typ)
+	}
+	types.locker.RUnlock()
+}
+
+// storeIfAbsent is same as store but keeps the type which is stored.
+func (types *Types) storeIfAbsent(key string, typ *Type, generation uint64) {
+	types.locker.RLock()
+	if types.generation == generation {
+		types.values.LoadOrStore(key, typ)
+	}
+	types.locker.RUnlock()
+}
+
+// invalidate drops types which are matched and begins a new generation, all types are dropped when match is nil.
+func (types *Types) invalidate(match func(typ *Type) (ok bool)) {
+	types.locker.Lock()
+	types.generation++
+	types.values.Range(func(key, value any) bool {
+		typ, ok := value.(*Type)
+		if !ok || match == nil || match(typ) {
+			types.values.Delete(key)
+		}
+		return true
+	})
+	types.locker.Unlock()
 }
 
 func (types *Types) parseType(ctx context.Context, spec *ast.TypeSpec, scope *TypeScope) (typ *Type, err error) {
@@ -466,7 +535,8 @@ func (types *Types) parseType(ctx context.Context, spec *ast.TypeSpec, scope *Ty
 		return
 	}
 
-	result, doErr, _ := types.group.Do(key, func() (v interface{}, err error) {
+	ctx, generation := types.withGeneration(ctx)
+	result, doErr, _ := types.group.Do(types.flight(key, generation), func() (v interface{}, err error) {
 		stored, loaded := types.values.Load(key)
 		if loaded {
 			v = stored.(*Type)
@@ -648,7 +718,7 @@ func (types *Types) parseType(ctx context.Context, spec *ast.TypeSpec, scope *Ty
 		if err != nil {
 			return
 		}
-		types.values.Store(key, result)
+		types.store(key, result, generation)
 		v = result
 		return
 	})
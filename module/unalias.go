@@ -0,0 +1,12 @@
+//go:build !go1.22
+
+package module
+
+import (
+	gotypes "go/types"
+)
+
+// unalias returns the type, aliases are not types before go1.22.
+func unalias(t gotypes.Type) gotypes.Type {
+	return t
+}
@@ -0,0 +1,12 @@
+//go:build go1.22
+
+package module
+
+import (
+	gotypes "go/types"
+)
+
+// unalias returns the type which alias refers to, aliases are types since go1.22 when gotypesalias is on.
+func unalias(t gotypes.Type) gotypes.Type {
+	return gotypes.Unalias(t)
+}
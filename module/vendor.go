@@ -0,0 +1,130 @@
+package module
+
+import (
+	"bufio"
+	"bytes"
+	"github.com/aacfactory/errors"
+	"github.com/aacfactory/forg/files"
+	"golang.org/x/mod/modfile"
+	"golang.org/x/mod/semver"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const (
+	vendorDirname          = "vendor"
+	vendorModulesFilename  = "modules.txt"
+	vendorDefaultGoVersion = "v1.14"
+)
+
+// goFlagsMod returns the value of -mod flag in GOFLAGS.
+func goFlagsMod() (mod string) {
+	for _, flag := range strings.Fields(os.Getenv("GOFLAGS")) {
+		flag = strings.TrimPrefix(flag, "-")
+		flag = strings.TrimPrefix(flag, "-")
+		if value, has := strings.CutPrefix(flag, "mod="); has {
+			mod = value
+		}
+	}
+	return
+}
+
+// vendorEnabled reports whether requires of the module are read from its vendor dir in the way of go command.
+// -mod=vendor in GOFLAGS enables it, -mod=mod and -mod=readonly disable it,
+// otherwise it is enabled when vendor/modules.txt exists and the go version of module is 1.14 or later.
+func vendorEnabled(fsys fs.FS, dir string) (enabled bool, err error) {
+	modulesFilename := filepath.ToSlash(filepath.Join(dir, vendorDirname, vendorModulesFilename))
+	exist := files.Exist(fsys, modulesFilename)
+	switch goFlagsMod() {
+	case "vendor":
+		if !exist {
+			err = errors.Warning("forg: -mod=vendor is set but vendor modules file was not found").WithMeta("file", modulesFilename)
+			return
+		}
+		enabled = true
+		return
+	case "mod", "readonly":
+		return
+	}
+	if !exist {
+		return
+	}
+	modFilename := filepath.ToSlash(filepath.Join(dir, "go.mod"))
+	data, readErr := files.ReadFile(fsys, modFilename)
+	if readErr != nil {
+		err = errors.Warning("forg: read mod file failed").WithCause(readErr).WithMeta("file", modFilename)
+		return
+	}
+	mf, parseErr := modfile.ParseLax(modFilename, data, nil)
+	if parseErr != nil {
+		err = errors.Warning("forg: parse mod file failed").WithCause(parseErr).WithMeta("file", modFilename)
+		return
+	}
+	enabled = mf.Go != nil && semver.Compare("v"+mf.Go.Version, vendorDefaultGoVersion) >= 0
+	return
+}
+
+// vendorRequires reads modules of vendor/modules.txt, packages of them are in vendor/<module path>.
+// Replacements are ignored, because replaced modules are copied into vendor dir by old path.
+func vendorRequires(fsys fs.FS, dir string) (requires Requires, err error) {
+	modulesFilename := filepath.ToSlash(filepath.Join(dir, vendorDirname, vendorModulesFilename))
+	data, readErr := files.ReadFile(fsys, modulesFilename)
+	if readErr != nil {
+		err = errors.Warning("forg: read vendor modules file failed").WithCause(readErr).WithMeta("file", modulesFilename)
+		return
+	}
+	requires = make(Requires, 0, 1)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "# ") {
+			continue
+		}
+		fields := strings.Fields(line[2:])
+		// '# path => dir' is a replacement of wildcard, it has no packages.
+		if len(fields) < 2 || fields[1] == "=>" {
+			continue
+		}
+		requires = append(requires, &Module{
+			Dir:      filepath.ToSlash(filepath.Join(dir, vendorDirname, filepath.FromSlash(fields[0]))),
+			Path:     fields[0],
+			Version:  fields[1],
+			Requires: nil,
+			Work:     nil,
+			Replace:  nil,
+			locker:   &sync.Mutex{},
+			parsed:   false,
+			services: nil,
+			types:    nil,
+			selected: true,
+		})
+	}
+	return
+}
+
+// parseSelected parses a module of build list, such as a module in vendor dir, requires of host are its requires.
+func (mod *Module) parseSelected(host *Module) {
+	if host == nil {
+		host = mod
+	}
+	requires, _ := host.requirements()
+	if host.selected {
+		requires = append(Requires{host}, requires...)
+	}
+	mod.Requires = make(Requires, 0, len(requires))
+	for _, require := range requires {
+		if require.Path != mod.Path {
+			mod.Requires = append(mod.Requires, require)
+		}
+	}
+	sort.Sort(sort.Reverse(mod.Requires))
+	mod.types = host.types
+	mod.config = host.config
+	mod.build = host.build
+	mod.sources = newSource(mod.Path, mod.Dir, mod.build, mod.fsys)
+	mod.parsed = true
+}
@@ -5,16 +5,83 @@ import (
 	"github.com/aacfactory/errors"
 	"github.com/aacfactory/forg/files"
 	"golang.org/x/mod/modfile"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 )
 
+const (
+	workFilename = "go.work"
+	// WorkOff disables the workspace, it is same as GOWORK=off.
+	WorkOff = "off"
+)
+
+// FindWork returns the go.work file of the module dir in the way of go command.
+// When GOWORK is off, no workspace is used, when GOWORK is a path, the file is used,
+// otherwise the go.work file is found by walking up from dir.
+// The filename is empty when no workspace is used.
+func FindWork(dir string) (filename string, err error) {
+	filename, err = FindWorkFS(files.Disk(), dir)
+	return
+}
+
+// FindWorkFS finds the go.work file in the file system, see FindWork.
+func FindWorkFS(fsys fs.FS, dir string) (filename string, err error) {
+	gowork := strings.TrimSpace(os.Getenv("GOWORK"))
+	if gowork == WorkOff {
+		return
+	}
+	if gowork != "" {
+		if !filepath.IsAbs(gowork) {
+			err = errors.Warning("forg: find work failed").
+				WithCause(errors.Warning("forg: GOWORK is not an absolute path")).WithMeta("GOWORK", gowork)
+			return
+		}
+		if !files.Exist(fsys, gowork) {
+			err = errors.Warning("forg: find work failed").
+				WithCause(errors.Warning("forg: file was not found")).WithMeta("GOWORK", gowork)
+			return
+		}
+		filename = filepath.ToSlash(gowork)
+		return
+	}
+	dir, absErr := filepath.Abs(dir)
+	if absErr != nil {
+		err = errors.Warning("forg: find work failed").WithCause(absErr).WithMeta("dir", dir)
+		return
+	}
+	for {
+		candidate := filepath.Join(dir, workFilename)
+		if files.Exist(fsys, candidate) {
+			filename = filepath.ToSlash(candidate)
+			return
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	return
+}
+
 type Work struct {
 	Filename string
 	Uses     []*Module
 	Replaces []*Module
 	parsed   bool
+	fsys     fs.FS
+}
+
+// fs returns the file system of work, it is the disk by default.
+func (work *Work) fs() (fsys fs.FS) {
+	fsys = work.fsys
+	if fsys == nil {
+		fsys = files.Disk()
+	}
+	return
 }
 
 func (work *Work) Use(path string) (v *Module, used bool) {
@@ -28,6 +95,25 @@ func (work *Work) Use(path string) (v *Module, used bool) {
 	return
 }
 
+// Replacement returns the replace of module version, a replace without version replaces all versions of the module.
+func (work *Work) Replacement(path string, version string) (v *Module, replaced bool) {
+	for _, replace := range work.Replaces {
+		if replace.Path != path {
+			continue
+		}
+		if replace.Version == version {
+			v = replace
+			replaced = true
+			return
+		}
+		if replace.Version == "" {
+			v = replace
+			replaced = true
+		}
+	}
+	return
+}
+
 func (work *Work) Parse() (err error) {
 	if work.parsed {
 		return
@@ -42,14 +128,15 @@ func (work *Work) Parse() (err error) {
 		}
 		path = absolute
 	}
-	if !files.ExistFile(path) {
+	fsys := work.fs()
+	if !files.Exist(fsys, path) {
 		err = errors.Warning("forg: parse work failed").
 			WithCause(errors.Warning("forg: file was not found").WithMeta("work", path))
 		return
 	}
 	dir := filepath.Dir(path)
 	path = filepath.ToSlash(path)
-	data, readErr := os.ReadFile(path)
+	data, readErr := files.ReadFile(fsys, path)
 	if readErr != nil {
 		err = errors.Warning("forg: parse work failed").WithMeta("work", path).WithCause(readErr)
 		return
@@ -71,13 +158,13 @@ func (work *Work) Parse() (err error) {
 				usePath = filepath.ToSlash(filepath.Join(dir, usePath))
 			}
 			moduleFile := filepath.ToSlash(filepath.Join(usePath, "go.mod"))
-			if !files.ExistFile(moduleFile) {
+			if !files.Exist(fsys, moduleFile) {
 				err = errors.Warning("forg: parse work failed").WithMeta("work", path).
 					WithCause(errors.Warning("forg: mod file was not found").
 						WithMeta("mod", moduleFile))
 				return
 			}
-			modData, readModErr := os.ReadFile(moduleFile)
+			modData, readModErr := files.ReadFile(fsys, moduleFile)
 			if readModErr != nil {
 				err = errors.Warning("forg: parse work failed").WithMeta("work", path).
 					WithCause(errors.Warning("forg: read mod file failed").WithCause(readModErr).WithMeta("mod", moduleFile))
@@ -105,35 +192,9 @@ func (work *Work) Parse() (err error) {
 	}
 	if file.Replace != nil && len(file.Replace) > 0 {
 		for _, replace := range file.Replace {
-			replaceDir := ""
-			if replace.New.Version != "" {
-				replaceDir = filepath.Join(PKG(), fmt.Sprintf("%s@%s", replace.New.Path, replace.New.Version))
-			} else {
-				replaceDir = filepath.Join(PKG(), replace.New.Path)
-			}
-			replaceDir = filepath.ToSlash(replaceDir)
-			if !files.ExistFile(replaceDir) {
-				err = errors.Warning("forg: parse work failed").WithMeta("work", path).
-					WithCause(errors.Warning("forg: replace dir was not found").WithMeta("replace", replaceDir))
-				return
-			}
-			moduleFile := filepath.ToSlash(filepath.Join(replaceDir, "mod.go"))
-			if !files.ExistFile(moduleFile) {
-				err = errors.Warning("forg: parse work failed").WithMeta("work", path).
-					WithCause(errors.Warning("forg: replace mod file was not found").
-						WithMeta("mod", moduleFile))
-				return
-			}
-			modData, readModErr := os.ReadFile(moduleFile)
-			if readModErr != nil {
-				err = errors.Warning("forg: parse work failed").WithMeta("work", path).
-					WithCause(errors.Warning("forg: read replace mod file failed").WithCause(readModErr).WithMeta("mod", moduleFile))
-				return
-			}
-			mf, parseModErr := modfile.Parse(moduleFile, modData, nil)
-			if parseModErr != nil {
-				err = errors.Warning("forg: parse work failed").WithMeta("work", path).
-					WithCause(errors.Warning("forg: parse replace mod file failed").WithCause(parseModErr).WithMeta("mod", moduleFile))
+			replaceModule, replaceErr := workReplacement(fsys, dir, replace)
+			if replaceErr != nil {
+				err = errors.Warning("forg: parse work failed").WithMeta("work", path).WithCause(replaceErr)
 				return
 			}
 			work.Replaces = append(work.Replaces, &Module{
@@ -142,18 +203,7 @@ func (work *Work) Parse() (err error) {
 				Version:  replace.Old.Version,
 				Requires: nil,
 				Work:     nil,
-				Replace: &Module{
-					Dir:      replaceDir,
-					Path:     mf.Module.Mod.Path,
-					Version:  mf.Module.Mod.Version,
-					Requires: nil,
-					Work:     nil,
-					Replace:  nil,
-					locker:   &sync.Mutex{},
-					parsed:   false,
-					services: nil,
-					types:    nil,
-				},
+				Replace:  replaceModule,
 				locker:   &sync.Mutex{},
 				parsed:   false,
 				services: nil,
@@ -164,3 +214,74 @@ func (work *Work) Parse() (err error) {
 	work.parsed = true
 	return
 }
+
+// workReplacement returns the module of replace target.
+// A local target is relative to the dir of work file, and a versioned target is in module cache.
+func workReplacement(fsys fs.FS, dir string, replace *modfile.Replace) (v *Module, err error) {
+	if replace.New.Version != "" {
+		// work may be parsed before module, so module cache is resolved here.
+		err = initPkgDir(fsys)
+		if err != nil {
+			return
+		}
+		replaceDir, replaceDirErr := ModuleCacheDir(replace.New.Path, replace.New.Version)
+		if replaceDirErr != nil {
+			err = replaceDirErr
+			return
+		}
+		if !files.Exist(fsys, replaceDir) {
+			err = errors.Warning("forg: replace dir was not found, download it by go mod download").
+				WithMeta("replace", fmt.Sprintf("%s %s", replace.New.Path, replace.New.Version)).WithMeta("dir", replaceDir)
+			return
+		}
+		v = &Module{
+			Dir:      replaceDir,
+			Path:     replace.New.Path,
+			Version:  replace.New.Version,
+			Requires: nil,
+			Work:     nil,
+			Replace:  nil,
+			locker:   &sync.Mutex{},
+			parsed:   false,
+			services: nil,
+			types:    nil,
+		}
+		return
+	}
+	replaceDir := filepath.FromSlash(replace.New.Path)
+	if !filepath.IsAbs(replaceDir) {
+		replaceDir = filepath.Join(dir, replaceDir)
+	}
+	replaceDir = filepath.ToSlash(filepath.Clean(replaceDir))
+	if !files.Exist(fsys, replaceDir) {
+		err = errors.Warning("forg: replace dir was not found").WithMeta("replace", replaceDir)
+		return
+	}
+	moduleFile := filepath.ToSlash(filepath.Join(replaceDir, "go.mod"))
+	if !files.Exist(fsys, moduleFile) {
+		err = errors.Warning("forg: replace mod file was not found").WithMeta("mod", moduleFile)
+		return
+	}
+	mf, readErr := readModFile(fsys, moduleFile)
+	if readErr != nil {
+		err = readErr
+		return
+	}
+	if mf.Module == nil {
+		err = errors.Warning("forg: replace mod file has no module directive").WithMeta("mod", moduleFile)
+		return
+	}
+	v = &Module{
+		Dir:      replaceDir,
+		Path:     mf.Module.Mod.Path,
+		Version:  "",
+		Requires: nil,
+		Work:     nil,
+		Replace:  nil,
+		locker:   &sync.Mutex{},
+		parsed:   false,
+		services: nil,
+		types:    nil,
+	}
+	return
+}
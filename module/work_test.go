@@ -1,7 +1,12 @@
 package module_test
 
 import (
+	"context"
+	"fmt"
 	"github.com/aacfactory/forg/module"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -17,3 +22,144 @@ func TestWork(t *testing.T) {
 		return
 	}
 }
+
+func TestFindWork(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "a", "b")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GOWORK", "")
+	filename, findErr := module.FindWork(dir)
+	if findErr != nil {
+		t.Fatalf("%+v", findErr)
+	}
+	if filename != "" && strings.HasPrefix(filename, filepath.ToSlash(root)) {
+		t.Fatalf("unexpected work: %s", filename)
+	}
+	work := filepath.Join(root, "go.work")
+	if err := os.WriteFile(work, []byte("go 1.20\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	filename, findErr = module.FindWork(dir)
+	if findErr != nil {
+		t.Fatalf("%+v", findErr)
+	}
+	if filename != filepath.ToSlash(work) {
+		t.Errorf("expect %s, got %s", work, filename)
+	}
+	t.Setenv("GOWORK", "off")
+	filename, findErr = module.FindWork(dir)
+	if findErr != nil || filename != "" {
+		t.Errorf("expect no work when GOWORK is off, got %q %v", filename, findErr)
+	}
+	other := filepath.Join(root, "other.work")
+	if err := os.WriteFile(other, []byte("go 1.20\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GOWORK", other)
+	filename, findErr = module.FindWork(dir)
+	if findErr != nil || filename != filepath.ToSlash(other) {
+		t.Errorf("expect %s, got %q %v", other, filename, findErr)
+	}
+	t.Setenv("GOWORK", "other.work")
+	if _, findErr = module.FindWork(dir); findErr == nil {
+		t.Error("expect error of relative GOWORK")
+	}
+}
+
+func TestWork_Replaces(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.CopyFS(dir, os.DirFS(filepath.Join("testdata", "workspace"))); err != nil {
+		t.Fatal(err)
+	}
+	cache := t.TempDir()
+	fork := filepath.Join(cache, "example.com", "fork@v1.1.0")
+	if err := os.MkdirAll(fork, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(fork, "go.mod"), []byte("module example.com/versioned\n\ngo 1.20\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(fork, "fork.go"), []byte("package versioned\n\ntype Fork struct {\n\tName string `json:\"name\"`\n}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GOENV", "off")
+	t.Setenv("GOMODCACHE", cache)
+	t.Setenv("GOFLAGS", "")
+	// absolute and versioned replaces
+	workFilename := filepath.Join(dir, "go.work")
+	extra := fmt.Sprintf("\nreplace example.com/abs v1.0.0 => %s\n\nreplace example.com/versioned => example.com/fork v1.1.0\n", filepath.ToSlash(filepath.Join(dir, "abs")))
+	workFile, openErr := os.OpenFile(workFilename, os.O_APPEND|os.O_WRONLY, 0644)
+	if openErr != nil {
+		t.Fatal(openErr)
+	}
+	_, writeErr := workFile.WriteString(extra)
+	_ = workFile.Close()
+	if writeErr != nil {
+		t.Fatal(writeErr)
+	}
+
+	work := &module.Work{
+		Filename: workFilename,
+		Uses:     nil,
+		Replaces: nil,
+	}
+	if parseErr := work.Parse(); parseErr != nil {
+		t.Fatalf("%+v", parseErr)
+	}
+	expects := []struct {
+		path    string
+		version string
+		dir     string
+	}{
+		{path: "example.com/local", version: "v1.0.0", dir: filepath.Join(dir, "local")},
+		{path: "example.com/local", version: "v2.0.0", dir: filepath.Join(dir, "local")},
+		{path: "example.com/abs", version: "v1.0.0", dir: filepath.Join(dir, "abs")},
+		{path: "example.com/abs", version: "v1.1.0", dir: ""},
+		{path: "example.com/versioned", version: "v1.0.0", dir: fork},
+	}
+	for _, expect := range expects {
+		replace, replaced := work.Replacement(expect.path, expect.version)
+		if expect.dir == "" {
+			if replaced {
+				t.Errorf("%s@%s: expect no replace, got %s", expect.path, expect.version, replace.Replace.Dir)
+			}
+			continue
+		}
+		if !replaced {
+			t.Errorf("%s@%s: expect replace", expect.path, expect.version)
+			continue
+		}
+		if replace.Replace.Dir != filepath.ToSlash(expect.dir) {
+			t.Errorf("%s@%s: expect %s, got %s", expect.path, expect.version, expect.dir, replace.Replace.Dir)
+		}
+	}
+
+	mod, newErr := module.NewWithWork(filepath.Join(dir, "app", "go.mod"), workFilename)
+	if newErr != nil {
+		t.Fatalf("%+v", newErr)
+	}
+	if parseErr := mod.Parse(context.TODO()); parseErr != nil {
+		t.Fatalf("%+v", parseErr)
+	}
+	types := []struct {
+		path  string
+		name  string
+		field string
+	}{
+		{path: "example.com/local", name: "Query", field: "Page"},
+		{path: "example.com/abs", name: "Item", field: "Id"},
+		{path: "example.com/versioned", name: "Fork", field: "Name"},
+	}
+	for _, expect := range types {
+		typ, typeErr := mod.ParseType(context.TODO(), expect.path, expect.name)
+		if typeErr != nil {
+			t.Errorf("%s.%s: %+v", expect.path, expect.name, typeErr)
+			continue
+		}
+		if len(typ.Elements) != 1 || typ.Elements[0].Name != expect.field {
+			t.Errorf("%s.%s: unexpected elements %+v", expect.path, expect.name, typ.Elements)
+		}
+	}
+}
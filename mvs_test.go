@@ -0,0 +1,80 @@
+package forg_test
+
+import (
+	"context"
+	"github.com/aacfactory/forg"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoad_BuildList(t *testing.T) {
+	dir := copyProject(t, filepath.Join("testdata", "standalone"))
+	cache := t.TempDir()
+	libV1 := "package lib\n\n// Query\n// @title query\ntype Query struct {\n\tOffset int `json:\"offset\"`\n}\n"
+	libV2 := "package lib\n\n// Query\n// @title query\ntype Query struct {\n\tPage int `json:\"page\"`\n}\n"
+	writes := map[string]string{
+		"go.mod":                "module example.com/standalone\n\ngo 1.20\n\nrequire (\n\tgithub.com/aacfactory/errors v1.13.4\n\texample.com/lib v1.0.0\n\texample.com/dep v1.0.0\n\texample.com/other v1.0.0\n)\n\nexclude example.com/lib v1.3.0\n",
+		"modules/users/find.go": "package users\n\nimport (\n\t\"context\"\n\t\"example.com/lib\"\n\t\"github.com/aacfactory/errors\"\n)\n\n// find\n// @fn find\n// @title Find user\nfunc find(ctx context.Context, param lib.Query) (result User, err errors.CodeError) {\n\treturn\n}\n",
+		// download cache
+		"@cache/cache/download/example.com/lib/@v/v1.0.0.mod":   "module example.com/lib\n\ngo 1.20\n",
+		"@cache/cache/download/example.com/lib/@v/v1.2.0.mod":   "module example.com/lib\n\ngo 1.20\n",
+		"@cache/cache/download/example.com/lib/@v/v1.4.0.mod":   "module example.com/lib\n\ngo 1.20\n\nretract v1.2.0 // broken page\n",
+		"@cache/cache/download/example.com/dep/@v/v1.0.0.mod":   "module example.com/dep\n\ngo 1.16\n\nrequire example.com/lib v1.2.0\n",
+		"@cache/cache/download/example.com/other/@v/v1.0.0.mod": "module example.com/other\n\ngo 1.16\n\nrequire example.com/lib v1.3.0\n",
+		// extracted modules
+		"@cache/example.com/lib@v1.0.0/lib.go": libV1,
+		"@cache/example.com/lib@v1.2.0/lib.go": libV2,
+		"@cache/example.com/lib@v1.3.0/lib.go": libV1,
+	}
+	for name, content := range writes {
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		if rest, isCache := strings.CutPrefix(name, "@cache/"); isCache {
+			filename = filepath.Join(cache, filepath.FromSlash(rest))
+		}
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("GOENV", "off")
+	t.Setenv("GOMODCACHE", cache)
+	t.Setenv("GOFLAGS", "")
+	p, loadErr := forg.Load(dir)
+	if loadErr != nil {
+		t.Fatalf("%+v", loadErr)
+	}
+	model, inspectErr := p.Inspect(context.TODO())
+	if inspectErr != nil {
+		t.Fatalf("%+v", inspectErr)
+	}
+	// lib v1.2.0 is required by dep, and lib v1.3.0 required by other is excluded.
+	var param *forg.TypeModel
+	for _, fn := range model.Services[0].Functions {
+		if fn.Name == "find" && fn.Param != nil {
+			param = fn.Param.Type
+		}
+	}
+	if param == nil || len(param.Elements) != 1 || param.Elements[0].Name != "Page" {
+		t.Fatalf("unexpected param: %+v", param)
+	}
+	selected := false
+	for _, require := range p.Mod.Requires {
+		if require.Path != "example.com/lib" {
+			continue
+		}
+		selected = true
+		if require.Version != "v1.2.0" {
+			t.Fatalf("unexpected version of lib: %s", require.Version)
+		}
+		if require.Retracted != "broken page" {
+			t.Fatalf("unexpected retracted of lib: %q", require.Retracted)
+		}
+	}
+	if !selected {
+		t.Fatal("lib was not selected")
+	}
+}
@@ -0,0 +1,69 @@
+package forg_test
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/aacfactory/forg"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWithPackagesLoader(t *testing.T) {
+	dir := copyProject(t, filepath.Join("testdata", "standalone"))
+	models := make([][]byte, 0, 2)
+	for _, options := range [][]forg.Option{nil, {forg.WithPackagesLoader()}} {
+		p, loadErr := forg.Load(dir, options...)
+		if loadErr != nil {
+			t.Fatalf("%+v", loadErr)
+		}
+		model, inspectErr := p.Inspect(context.TODO())
+		if inspectErr != nil {
+			t.Fatalf("%+v", inspectErr)
+		}
+		encoded, _ := json.Marshal(model.Services)
+		models = append(models, encoded)
+	}
+	if string(models[0]) != string(models[1]) {
+		t.Fatalf("models of loaders are different\nast:      %s\npackages: %s", models[0], models[1])
+	}
+}
+
+func TestWithPackagesLoader_DotImport(t *testing.T) {
+	dir := copyProject(t, filepath.Join("testdata", "standalone"))
+	shared := "package shared\n\n// Page\n// @title page\ntype Page struct {\n\t// Offset\n\t// @title offset\n\tOffset int `json:\"offset\"`\n\tLimit  int `json:\"limit\"`\n}\n\ntype Query = Page\n"
+	if err := os.MkdirAll(filepath.Join(dir, "shared"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "shared", "shared.go"), []byte(shared), 0644); err != nil {
+		t.Fatal(err)
+	}
+	find := "package users\n\nimport (\n\t\"context\"\n\t. \"example.com/standalone/shared\"\n\t\"github.com/aacfactory/errors\"\n)\n\n// find\n// @fn find\n// @title Find user\nfunc find(ctx context.Context, param Query) (result User, err errors.CodeError) {\n\treturn\n}\n"
+	if err := os.WriteFile(filepath.Join(dir, "modules", "users", "find.go"), []byte(find), 0644); err != nil {
+		t.Fatal(err)
+	}
+	p, loadErr := forg.Load(dir, forg.WithPackagesLoader())
+	if loadErr != nil {
+		t.Fatalf("%+v", loadErr)
+	}
+	model, inspectErr := p.Inspect(context.TODO())
+	if inspectErr != nil {
+		t.Fatalf("%+v", inspectErr)
+	}
+	var find0 *forg.FunctionModel
+	for _, fn := range model.Services[0].Functions {
+		if fn.Name == "find" {
+			find0 = fn
+		}
+	}
+	if find0 == nil || find0.Param == nil {
+		t.Fatalf("find was not parsed: %+v", model.Services[0].Functions)
+	}
+	param := find0.Param.Type
+	if param.Path != "example.com/standalone/shared" || param.Name != "Page" || param.Annotations["title"] != "page" {
+		t.Fatalf("unexpected param type: %+v", param)
+	}
+	if len(param.Elements) != 2 || param.Elements[0].Annotations["title"] != "offset" || param.Elements[0].Tags["json"] != "offset" {
+		t.Fatalf("unexpected fields of param type: %+v", param.Elements)
+	}
+}
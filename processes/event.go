@@ -0,0 +1,71 @@
+package processes
+
+import (
+	"time"
+)
+
+// Event is the kind of Result, results of units are UnitFinished, others are sent only when the process is created WithEvents.
+type Event int
+
+const (
+	UnitFinished Event = iota
+	UnitStarted
+	StepStarted
+	StepFinished
+	ProcessFinished
+)
+
+func (event Event) String() string {
+	switch event {
+	case UnitFinished:
+		return "unitFinished"
+	case UnitStarted:
+		return "unitStarted"
+	case StepStarted:
+		return "stepStarted"
+	case StepFinished:
+		return "stepFinished"
+	case ProcessFinished:
+		return "processFinished"
+	default:
+		return "unknown"
+	}
+}
+
+// WithEvents sends results of starts of units and steps, finishes of steps and the finish of process besides results of units,
+// they are not counted by Units, so consumers which count results should check Event of results.
+func WithEvents() Option {
+	return func(options *Options) {
+		options.Events = true
+	}
+}
+
+// final reports whether the result is the last result of the unit.
+func (result Result) final() (ok bool) {
+	ok = result.Event == UnitFinished && !result.Retrying
+	return
+}
+
+func (step *Step) event(event Event, unitNo int64, unitNum int64, attempt int64, at time.Time, duration time.Duration, err error) Result {
+	return Result{
+		StepNo:   step.no,
+		StepNum:  step.num,
+		StepName: step.name,
+		UnitNo:   unitNo,
+		UnitNum:  unitNum,
+		Attempt:  attempt,
+		Retrying: false,
+		Data:     nil,
+		Error:    err,
+		Event:    event,
+		Time:     at,
+		Duration: duration,
+	}
+}
+
+// started sends the StepStarted event when events are enabled.
+func (step *Step) started(begin time.Time, unitNum int64) {
+	if step.events {
+		step.resultCh <- step.event(StepStarted, 0, unitNum, 0, begin, 0, nil)
+	}
+}
@@ -0,0 +1,169 @@
+package processes
+
+import (
+	"context"
+	"fmt"
+	"github.com/aacfactory/errors"
+	"reflect"
+	"strings"
+)
+
+const (
+	cycleDependenciesMessage = "processes: units have cycle dependencies"
+	dependencyFailedMessage  = "processes: dependency of unit failed"
+)
+
+// IsCycleDependenciesErr reports whether the step failed by units which depend on each other.
+func IsCycleDependenciesErr(err error) (ok bool) {
+	ok = containsMessage(err, cycleDependenciesMessage)
+	return
+}
+
+// IsDependencyFailedErr reports whether the unit was skipped by the failure of its dependency.
+func IsDependencyFailedErr(err error) (ok bool) {
+	ok = containsMessage(err, dependencyFailedMessage)
+	return
+}
+
+type messageError string
+
+func (e messageError) Error() string {
+	return string(e)
+}
+
+// containsMessage reports whether the err or its causes has the message, Contains of code error matches messages by Error of target.
+func containsMessage(err error, message string) (ok bool) {
+	if err == nil {
+		return
+	}
+	ok = errors.Map(err).Contains(messageError(message))
+	return
+}
+
+// DependentUnit is handled after its dependencies are succeeded. Dependencies are pointer units in the same step,
+// others are treated as done, because previous steps are finished before the step begins.
+type DependentUnit interface {
+	Unit
+	Dependencies() (units []Unit)
+}
+
+// Depend makes the unit handled after the dependencies are succeeded, it is skipped when one of them failed, see IsDependencyFailedErr.
+func Depend(unit Unit, dependencies ...Unit) Unit {
+	return &dependentUnit{
+		unit:         unit,
+		dependencies: dependencies,
+	}
+}
+
+type dependentUnit struct {
+	unit         Unit
+	dependencies []Unit
+}
+
+func (unit *dependentUnit) Handle(ctx context.Context) (result interface{}, err error) {
+	result, err = unit.unit.Handle(ctx)
+	return
+}
+
+func (unit *dependentUnit) Dependencies() (units []Unit) {
+	units = unit.dependencies
+	return
+}
+
+// unitKey is the identity of a unit in graph, units are compared by pointers, because dynamic types of units may be not comparable.
+type unitKey struct {
+	typ reflect.Type
+	ptr uintptr
+}
+
+// keyOfUnit returns the identity of the unit, ok is false when the unit is not a pointer, so it can not be a dependency in the step.
+func keyOfUnit(unit Unit) (key unitKey, ok bool) {
+	if unit == nil {
+		return
+	}
+	value := reflect.ValueOf(unit)
+	if value.Kind() != reflect.Pointer || value.IsNil() {
+		return
+	}
+	key = unitKey{
+		typ: value.Type(),
+		ptr: value.Pointer(),
+	}
+	ok = true
+	return
+}
+
+// graph returns indexes of dependencies of units, err is a cycle dependencies error when units depend on each other.
+// Only units which are pointers can be dependencies, others are treated as done like units out of the step.
+func (step *Step) graph() (dependencies [][]int, err error) {
+	dependencies = make([][]int, len(step.units))
+	dependent := false
+	for _, unit := range step.units {
+		if _, ok := unit.(DependentUnit); ok {
+			dependent = true
+			break
+		}
+	}
+	if !dependent {
+		return
+	}
+	indexes := make(map[unitKey]int, len(step.units))
+	for i, unit := range step.units {
+		if key, ok := keyOfUnit(unit); ok {
+			indexes[key] = i
+		}
+	}
+	for i, unit := range step.units {
+		withDependencies, ok := unit.(DependentUnit)
+		if !ok {
+			continue
+		}
+		for _, dependency := range withDependencies.Dependencies() {
+			key, has := keyOfUnit(dependency)
+			if !has {
+				continue
+			}
+			if idx, has := indexes[key]; has {
+				dependencies[i] = append(dependencies[i], idx)
+			}
+		}
+	}
+	// units which are left by Kahn's algorithm are in or after cycles
+	remaining := make([]int, len(step.units))
+	dependents := make([][]int, len(step.units))
+	for i, deps := range dependencies {
+		remaining[i] = len(deps)
+		for _, dep := range deps {
+			dependents[dep] = append(dependents[dep], i)
+		}
+	}
+	queue := make([]int, 0, len(step.units))
+	for i, n := range remaining {
+		if n == 0 {
+			queue = append(queue, i)
+		}
+	}
+	visited := 0
+	for len(queue) > 0 {
+		i := queue[0]
+		queue = queue[1:]
+		visited++
+		for _, dependent := range dependents[i] {
+			remaining[dependent]--
+			if remaining[dependent] == 0 {
+				queue = append(queue, dependent)
+			}
+		}
+	}
+	if visited == len(step.units) {
+		return
+	}
+	units := make([]string, 0, 1)
+	for i, n := range remaining {
+		if n > 0 {
+			units = append(units, fmt.Sprintf("%d", i+1))
+		}
+	}
+	err = errors.Warning(cycleDependenciesMessage).WithMeta("step", step.name).WithMeta("units", strings.Join(units, ","))
+	return
+}
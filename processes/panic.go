@@ -0,0 +1,76 @@
+package processes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/aacfactory/errors"
+	"runtime/debug"
+)
+
+const (
+	unitPanickedMessage = "processes: unit panicked"
+)
+
+// IsPanicErr reports whether the unit failed by a panic, the error of the unit result is a *PanicError,
+// and errors of steps which have failed units contain it.
+func IsPanicErr(err error) (ok bool) {
+	ok = containsMessage(err, unitPanickedMessage)
+	return
+}
+
+// PanicError is the error of a unit which panicked, the value and the stack are in meta too,
+// so they are kept when the error is encoded or chained.
+type PanicError struct {
+	errors.CodeError
+	// Value is the value which was passed to panic.
+	Value interface{}
+	// Stack is the stack trace of the goroutine where the unit panicked.
+	Stack []byte
+}
+
+func (e *PanicError) MarshalJSON() (p []byte, err error) {
+	p, err = json.Marshal(e.CodeError)
+	return
+}
+
+// PanicPolicy decides what a step does after a unit panicked, the panic is always recovered as the error of the unit.
+type PanicPolicy int
+
+const (
+	// StopOnPanic cancels other units of the step, units which are not handled are failed by the cancellation,
+	// and the process is stopped like other failed steps. It is the default policy.
+	StopOnPanic PanicPolicy = iota
+	// ContinueOnPanic treats the panicked unit as a failed unit, other units of the step are still handled,
+	// and next steps are handled when the step failed only by panicked units and units which depend on them.
+	// It is opt-in by WithPanicPolicy.
+	ContinueOnPanic
+)
+
+// WithPanicPolicy sets what steps do after a unit panicked, StopOnPanic is used by default.
+func WithPanicPolicy(policy PanicPolicy) Option {
+	return func(options *Options) {
+		options.Panic = policy
+	}
+}
+
+// call handles the unit and recovers its panic as an error, panicked units are not retried.
+func (step *Step) call(ctx context.Context, unitNo int64, unit Unit) (data interface{}, err error) {
+	defer func() {
+		value := recover()
+		if value == nil {
+			return
+		}
+		stack := debug.Stack()
+		data = nil
+		err = &PanicError{
+			CodeError: errors.Warning(unitPanickedMessage).
+				WithMeta("step", step.name).WithMeta("unit", fmt.Sprintf("%d", unitNo)).
+				WithMeta("panic", fmt.Sprintf("%v", value)).WithMeta("stack", string(stack)),
+			Value: value,
+			Stack: stack,
+		}
+	}()
+	data, err = unit.Handle(ctx)
+	return
+}
@@ -6,12 +6,56 @@ import (
 	"time"
 )
 
+const (
+	abortedMessage = "forg: abort"
+)
+
 var (
-	ErrAborted = errors.Warning("forg: abort")
+	ErrAborted = errors.Warning(abortedMessage)
 )
 
-func New() *Process {
+type Options struct {
+	// MaxParallel is the max number of units which are handled at the same time in a step, no limit when it is not positive.
+	MaxParallel int
+	// Ordered sends results of units in a step by the order of units instead of the order of completion.
+	Ordered bool
+	// Retry handles failed units again, units are not retried when it is nil, see RetryableUnit.
+	Retry *RetryPolicy
+	// Events sends events of units, steps and the process besides results of units, see Event.
+	Events bool
+	// Panic decides what a step does after a unit panicked, see PanicPolicy.
+	Panic PanicPolicy
+}
+
+type Option func(options *Options)
+
+// WithMaxParallel limits the number of units which are handled at the same time in a step.
+func WithMaxParallel(n int) Option {
+	return func(options *Options) {
+		options.MaxParallel = n
+	}
+}
+
+// WithOrderedResults sends results of units in a step by the order of units, so the results are deterministic.
+func WithOrderedResults() Option {
+	return func(options *Options) {
+		options.Ordered = true
+	}
+}
+
+func New(options ...Option) *Process {
+	opt := Options{
+		MaxParallel: 0,
+		Ordered:     false,
+		Retry:       nil,
+		Events:      false,
+		Panic:       StopOnPanic,
+	}
+	for _, option := range options {
+		option(&opt)
+	}
 	return &Process{
+		options:  opt,
 		units:    0,
 		steps:    make([]*Step, 0, 1),
 		resultCh: make(chan Result, 512),
@@ -28,6 +72,7 @@ type ProcessController interface {
 }
 
 type Process struct {
+	options  Options
 	units    int64
 	steps    []*Step
 	resultCh chan Result
@@ -36,13 +81,28 @@ type Process struct {
 }
 
 func (p *Process) Add(name string, units ...Unit) {
+	p.AddWithOptions(name, units)
+}
+
+// AddWithOptions adds a step which options override options of the process, such as a lower limit of parallel units.
+func (p *Process) AddWithOptions(name string, units []Unit, options ...Option) {
+	opt := p.options
+	for _, option := range options {
+		option(&opt)
+	}
 	no := int64(len(p.steps) + 1)
 	p.steps = append(p.steps, &Step{
-		no:       no,
-		name:     name,
-		num:      0,
-		units:    units,
-		resultCh: p.resultCh,
+		no:          no,
+		name:        name,
+		num:         0,
+		units:       units,
+		loader:      nil,
+		maxParallel: opt.MaxParallel,
+		ordered:     opt.Ordered,
+		retry:       opt.Retry,
+		events:      opt.Events,
+		panicPolicy: opt.Panic,
+		resultCh:    p.resultCh,
 	})
 	for _, step := range p.steps {
 		step.num = no
@@ -52,6 +112,13 @@ func (p *Process) Add(name string, units ...Unit) {
 	}
 }
 
+// AddLazy adds a step which units are made by loader when the step begins,
+// so they can depend on results of previous steps. Units of lazy steps are not counted by Units.
+func (p *Process) AddLazy(name string, loader func(ctx context.Context) (units []Unit, err error)) {
+	p.Add(name)
+	p.steps[len(p.steps)-1].loader = loader
+}
+
 func (p *Process) Steps() (n int64) {
 	n = int64(len(p.steps))
 	return
@@ -65,18 +132,26 @@ func (p *Process) Units() (n int64) {
 func (p *Process) Start(ctx context.Context) (results <-chan Result) {
 	ctx, p.cancel = context.WithCancel(ctx)
 	go func(ctx context.Context, p *Process, result chan Result) {
+		begin := time.Now()
+		var failed error
 		for _, step := range p.steps {
 			stop := false
 			select {
 			case <-ctx.Done():
+				failed = errors.Warning(abortedMessage).WithCause(ctx.Err())
 				result <- Result{
 					StepNo:   0,
 					StepNum:  0,
 					StepName: "",
 					UnitNo:   0,
 					UnitNum:  0,
+					Attempt:  0,
+					Retrying: false,
 					Data:     nil,
-					Error:    ErrAborted.WithCause(ctx.Err()),
+					Error:    failed,
+					Event:    UnitFinished,
+					Time:     time.Now(),
+					Duration: 0,
 				}
 				stop = true
 				p.closedCh <- struct{}{}
@@ -84,6 +159,11 @@ func (p *Process) Start(ctx context.Context) (results <-chan Result) {
 			default:
 				err := step.Execute(ctx)
 				if err != nil {
+					failed = err
+					// steps which failed only by panicked units are passed when the panic policy is ContinueOnPanic
+					if failure, ok := err.(*stepError); ok && failure.panicked() && p.options.Panic == ContinueOnPanic {
+						break
+					}
 					stop = true
 					p.closedCh <- struct{}{}
 				}
@@ -93,6 +173,22 @@ func (p *Process) Start(ctx context.Context) (results <-chan Result) {
 				break
 			}
 		}
+		if p.options.Events {
+			result <- Result{
+				StepNo:   0,
+				StepNum:  int64(len(p.steps)),
+				StepName: "",
+				UnitNo:   0,
+				UnitNum:  0,
+				Attempt:  0,
+				Retrying: false,
+				Data:     nil,
+				Error:    failed,
+				Event:    ProcessFinished,
+				Time:     time.Now(),
+				Duration: time.Since(begin),
+			}
+		}
 		close(result)
 		close(p.closedCh)
 	}(ctx, p, p.resultCh)
@@ -115,7 +211,9 @@ func (p *Process) Abort(timeout time.Duration) (err error) {
 	return
 }
 
+// IsAbortErr reports whether the err is made by Abort or a canceled context, errors are matched by messages,
+// so code errors are not formatted, which is not safe for concurrent use.
 func IsAbortErr(err error) (ok bool) {
-	ok = errors.Map(err).Contains(ErrAborted) || errors.Map(err).Contains(context.Canceled)
+	ok = containsMessage(err, abortedMessage) || containsMessage(err, context.Canceled.Error())
 	return
 }
@@ -2,9 +2,13 @@ package processes_test
 
 import (
 	"context"
+	stderrors "errors"
 	"fmt"
 	"github.com/aacfactory/errors"
 	"github.com/aacfactory/forg/processes"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -83,3 +87,399 @@ func TestParallelUnits(t *testing.T) {
 		fmt.Println("result:", result.String())
 	}
 }
+
+func TestLazyStep(t *testing.T) {
+	process := processes.New()
+	first := &WorkUnit{
+		name: "first",
+		no:   0,
+	}
+	process.Add("first", first)
+	process.AddLazy("lazy", func(ctx context.Context) (units []processes.Unit, err error) {
+		units = []processes.Unit{&WorkUnit{
+			name: fmt.Sprintf("after:%d", first.value),
+			no:   2,
+		}}
+		return
+	})
+	n := 0
+	for result := range process.Start(context.TODO()) {
+		if result.Error != nil {
+			t.Errorf("%+v", result.Error)
+		}
+		fmt.Println("result:", result.String(), result.Data)
+		n++
+	}
+	if n != 2 {
+		t.Errorf("expected 2 results, got %d", n)
+	}
+}
+
+type CountUnit struct {
+	running *int64
+	max     *int64
+	delay   time.Duration
+}
+
+func (unit *CountUnit) Handle(ctx context.Context) (result interface{}, err error) {
+	n := atomic.AddInt64(unit.running, 1)
+	for {
+		prev := atomic.LoadInt64(unit.max)
+		if n <= prev || atomic.CompareAndSwapInt64(unit.max, prev, n) {
+			break
+		}
+	}
+	time.Sleep(unit.delay)
+	atomic.AddInt64(unit.running, -1)
+	return
+}
+
+func TestWithMaxParallel(t *testing.T) {
+	running, max := []int64{0, 0}, []int64{0, 0}
+	steps := make([][]processes.Unit, 2)
+	for step := range steps {
+		for i := 0; i < 12; i++ {
+			steps[step] = append(steps[step], &CountUnit{
+				running: &running[step],
+				max:     &max[step],
+				delay:   time.Duration(12-i) * time.Millisecond,
+			})
+		}
+	}
+	process := processes.New(processes.WithMaxParallel(3))
+	process.Add("limited", steps[0]...)
+	process.AddWithOptions("ordered", steps[1], processes.WithMaxParallel(5), processes.WithOrderedResults())
+	limited, ordered := 0, int64(0)
+	for result := range process.Start(context.TODO()) {
+		if result.Error != nil {
+			t.Fatalf("%+v", result.Error)
+		}
+		if result.StepNo == 1 {
+			limited++
+			continue
+		}
+		if result.UnitNo != ordered+1 {
+			t.Errorf("expect unit %d, got %d", ordered+1, result.UnitNo)
+		}
+		ordered = result.UnitNo
+	}
+	if limited != 12 || ordered != 12 {
+		t.Fatalf("expect 12 results of each step, got %d and %d", limited, ordered)
+	}
+	if max[0] > 3 || max[1] > 5 {
+		t.Errorf("expect at most 3 and 5 running units, got %d and %d", max[0], max[1])
+	}
+}
+
+type TraceUnit struct {
+	name   string
+	fail   bool
+	locker *sync.Mutex
+	trace  *[]string
+}
+
+func (unit *TraceUnit) Handle(ctx context.Context) (result interface{}, err error) {
+	time.Sleep(5 * time.Millisecond)
+	unit.locker.Lock()
+	*unit.trace = append(*unit.trace, unit.name)
+	unit.locker.Unlock()
+	if unit.fail {
+		err = errors.ServiceError("failed")
+	}
+	result = unit.name
+	return
+}
+
+type CycleUnit struct {
+	dependencies []processes.Unit
+}
+
+func (unit *CycleUnit) Handle(ctx context.Context) (result interface{}, err error) {
+	return
+}
+
+func (unit *CycleUnit) Dependencies() (units []processes.Unit) {
+	units = unit.dependencies
+	return
+}
+
+func TestDepend(t *testing.T) {
+	locker, trace := &sync.Mutex{}, make([]string, 0, 1)
+	unit := func(name string, fail bool) *TraceUnit {
+		return &TraceUnit{
+			name:   name,
+			fail:   fail,
+			locker: locker,
+			trace:  &trace,
+		}
+	}
+	a, b, broken := unit("a", false), unit("b", false), unit("broken", true)
+	write := processes.Depend(unit("write", false), a, b)
+	process := processes.New(processes.WithOrderedResults())
+	process.Add("graph", write, a, b, broken, processes.Depend(unit("skipped", false), broken, write))
+	results := make([]processes.Result, 0, 1)
+	for result := range process.Start(context.TODO()) {
+		results = append(results, result)
+	}
+	if len(results) != 5 {
+		t.Fatalf("expect 5 results, got %d", len(results))
+	}
+	if results[0].Error != nil || results[0].Data != "write" {
+		t.Errorf("unexpected result of write: %+v", results[0])
+	}
+	if !processes.IsDependencyFailedErr(results[4].Error) {
+		t.Errorf("expect dependency failed, got %+v", results[4].Error)
+	}
+	if len(trace) != 4 || trace[3] != "write" {
+		t.Errorf("expect write is handled after a and b, got %v", trace)
+	}
+
+	x, y := &CycleUnit{}, &CycleUnit{}
+	x.dependencies, y.dependencies = []processes.Unit{y}, []processes.Unit{x}
+	cycle := processes.New()
+	cycle.Add("cycle", a, x, y)
+	n := 0
+	for result := range cycle.Start(context.TODO()) {
+		if !processes.IsCycleDependenciesErr(result.Error) {
+			t.Errorf("expect cycle dependencies, got %+v", result.Error)
+		}
+		n++
+	}
+	if n != 1 {
+		t.Errorf("expect 1 result of cycle, got %d", n)
+	}
+}
+
+type FlakyUnit struct {
+	failures int
+	attempts int
+	policy   *processes.RetryPolicy
+}
+
+func (unit *FlakyUnit) Handle(ctx context.Context) (result interface{}, err error) {
+	unit.attempts++
+	if unit.attempts <= unit.failures {
+		err = errors.Warning("flaky")
+		return
+	}
+	result = unit.attempts
+	return
+}
+
+type OwnPolicyUnit struct {
+	FlakyUnit
+}
+
+func (unit *OwnPolicyUnit) RetryPolicy() (policy *processes.RetryPolicy) {
+	policy = unit.policy
+	return
+}
+
+func TestWithRetry(t *testing.T) {
+	flaky := &FlakyUnit{failures: 2}
+	broken := &FlakyUnit{failures: 5}
+	fatal := &FlakyUnit{failures: 1}
+	own := &OwnPolicyUnit{FlakyUnit{failures: 1, policy: nil}}
+	process := processes.New(processes.WithOrderedResults(), processes.WithRetry(processes.RetryPolicy{
+		MaxAttempts: 3,
+		Backoff:     processes.ExponentialBackoff(time.Millisecond, 2*time.Millisecond),
+		Retryable:   nil,
+	}))
+	process.Add("retry", flaky, broken, own)
+	attempts := make(map[int64][]int64)
+	for result := range process.Start(context.TODO()) {
+		if result.Retrying && result.Error == nil {
+			t.Errorf("retrying result must have error: %s", result.String())
+		}
+		attempts[result.UnitNo] = append(attempts[result.UnitNo], result.Attempt)
+		if !result.Retrying && result.UnitNo == 1 && (result.Error != nil || result.Data != 3) {
+			t.Errorf("unexpected result of flaky unit: %+v", result)
+		}
+	}
+	if fmt.Sprint(attempts) != "map[1:[1 2 3] 2:[1 2 3] 3:[1]]" {
+		t.Errorf("unexpected attempts: %v", attempts)
+	}
+	if own.attempts != 1 {
+		t.Errorf("expect nil policy of unit disables retries, got %d attempts", own.attempts)
+	}
+	classified := processes.New(processes.WithRetry(processes.RetryPolicy{
+		MaxAttempts: 3,
+		Backoff:     nil,
+		Retryable: func(err error) (ok bool) {
+			return
+		},
+	}))
+	classified.Add("classified", fatal)
+	for range classified.Start(context.TODO()) {
+	}
+	if fatal.attempts != 1 {
+		t.Errorf("expect error which is not retryable is not retried, got %d attempts", fatal.attempts)
+	}
+	backoff := processes.ExponentialBackoff(10*time.Millisecond, 50*time.Millisecond)
+	if delays := fmt.Sprint(backoff(2), backoff(3), backoff(4), backoff(5)); delays != "10ms 20ms 40ms 50ms" {
+		t.Errorf("unexpected delays: %s", delays)
+	}
+}
+
+func TestWithEvents(t *testing.T) {
+	locker, trace := &sync.Mutex{}, make([]string, 0, 1)
+	process := processes.New(processes.WithEvents(), processes.WithOrderedResults())
+	process.Add("first", &TraceUnit{name: "a", locker: locker, trace: &trace}, &TraceUnit{name: "b", locker: locker, trace: &trace})
+	process.AddLazy("second", func(ctx context.Context) (units []processes.Unit, err error) {
+		units = []processes.Unit{&TraceUnit{name: "c", fail: true, locker: locker, trace: &trace}}
+		return
+	})
+	events := make([]string, 0, 1)
+	started := make(map[string]bool)
+	var last processes.Result
+	for result := range process.Start(context.TODO()) {
+		events = append(events, fmt.Sprintf("%d:%d:%s", result.StepNo, result.UnitNo, result.Event))
+		if (result.Event == processes.StepStarted || result.Event == processes.StepFinished) && result.UnitNum != 3-result.StepNo {
+			t.Errorf("expect number of units of step, got %s with %d units", result.String(), result.UnitNum)
+		}
+		if result.Time.IsZero() {
+			t.Errorf("expect time of result: %s", result.String())
+		}
+		key := fmt.Sprintf("%d:%d", result.StepNo, result.UnitNo)
+		switch result.Event {
+		case processes.UnitStarted:
+			started[key] = true
+			break
+		case processes.UnitFinished:
+			if !started[key] {
+				t.Errorf("expect unit is started before it is finished: %s", result.String())
+			}
+			if result.Duration < 5*time.Millisecond {
+				t.Errorf("expect duration of unit, got %s", result.Duration)
+			}
+			break
+		default:
+			break
+		}
+		last = result
+	}
+	expect := "[1:0:stepStarted 1:1:unitStarted 1:1:unitFinished 1:2:unitStarted 1:2:unitFinished 1:0:stepFinished " +
+		"2:0:stepStarted 2:1:unitStarted 2:1:unitFinished 2:0:stepFinished 0:0:processFinished]"
+	if fmt.Sprint(events) != expect {
+		t.Errorf("unexpected events: %v", events)
+	}
+	if last.Error == nil || last.Duration < 10*time.Millisecond {
+		t.Errorf("expect failed process with duration, got %s", last.String())
+	}
+	if s := (processes.Result{StepNo: 1, StepNum: 2, StepName: "first", Event: processes.StepFinished, Duration: time.Second}).String(); s != "[1/2] first succeed in 1s" {
+		t.Errorf("unexpected string of step finished event: %s", s)
+	}
+	plain := processes.New(processes.WithOrderedResults())
+	plain.Add("plain", &TraceUnit{name: "d", locker: locker, trace: &trace})
+	n := 0
+	for result := range plain.Start(context.TODO()) {
+		if result.Event != processes.UnitFinished {
+			t.Errorf("expect no events without WithEvents, got %s", result.Event)
+		}
+		n++
+	}
+	if n != 1 {
+		t.Errorf("expect 1 result, got %d", n)
+	}
+}
+
+type PanicUnit struct {
+	attempts int
+}
+
+func (unit *PanicUnit) Handle(ctx context.Context) (result interface{}, err error) {
+	unit.attempts++
+	panic("boom")
+}
+
+func TestWithPanicPolicy(t *testing.T) {
+	locker, trace := &sync.Mutex{}, make([]string, 0, 1)
+	panicked := &PanicUnit{}
+	process := processes.New(processes.WithOrderedResults(), processes.WithEvents(), processes.WithPanicPolicy(processes.ContinueOnPanic), processes.WithRetry(processes.RetryPolicy{
+		MaxAttempts: 3,
+		Backoff:     nil,
+		Retryable:   nil,
+	}))
+	process.Add("continue", panicked, &TraceUnit{name: "a", locker: locker, trace: &trace})
+	process.Add("next", &TraceUnit{name: "next", locker: locker, trace: &trace})
+	results := make([]processes.Result, 0, 2)
+	var finished error
+	for result := range process.Start(context.TODO()) {
+		if result.Event == processes.ProcessFinished {
+			finished = result.Error
+		}
+		if result.Event == processes.UnitFinished {
+			results = append(results, result)
+		}
+	}
+	if len(results) != 3 || results[1].Error != nil || results[2].Error != nil {
+		t.Fatalf("expect other units and next steps are handled after a panic, got %v", results)
+	}
+	var processPanicErr *processes.PanicError
+	if !stderrors.As(finished, &processPanicErr) || !processes.IsPanicErr(finished) {
+		t.Errorf("expect error of process has the panic error, got %+v", finished)
+	}
+	panicErr, ok := results[0].Error.(*processes.PanicError)
+	if !ok || !processes.IsPanicErr(results[0].Error) {
+		t.Fatalf("expect panic error, got %+v", results[0].Error)
+	}
+	if panicErr.Value != "boom" || !strings.Contains(string(panicErr.Stack), "PanicUnit") {
+		t.Errorf("expect value and stack of panic, got %v\n%s", panicErr.Value, panicErr.Stack)
+	}
+	if panicked.attempts != 1 {
+		t.Errorf("expect panicked unit is not retried, got %d attempts", panicked.attempts)
+	}
+	stopped := processes.New(processes.WithOrderedResults(), processes.WithMaxParallel(1))
+	stopped.Add("stop", &PanicUnit{}, &TraceUnit{name: "b", locker: locker, trace: &trace})
+	results = results[:0]
+	for result := range stopped.Start(context.TODO()) {
+		results = append(results, result)
+	}
+	if len(results) != 2 || !processes.IsPanicErr(results[0].Error) || !processes.IsAbortErr(results[1].Error) {
+		t.Fatalf("expect units after the panic are canceled, got %v", results)
+	}
+	if fmt.Sprint(trace) != "[a next]" {
+		t.Errorf("expect canceled unit is not handled, got %v", trace)
+	}
+}
+
+type FuncUnit func(ctx context.Context) (result interface{}, err error)
+
+func (unit FuncUnit) Handle(ctx context.Context) (result interface{}, err error) {
+	result, err = unit(ctx)
+	return
+}
+
+type SliceUnit struct {
+	values []string
+}
+
+func (unit SliceUnit) Handle(ctx context.Context) (result interface{}, err error) {
+	result = len(unit.values)
+	return
+}
+
+func TestDepend_UncomparableUnits(t *testing.T) {
+	locker, trace := &sync.Mutex{}, make([]string, 0, 1)
+	fn := FuncUnit(func(ctx context.Context) (result interface{}, err error) {
+		return
+	})
+	slice := SliceUnit{values: []string{"a"}}
+	first := &TraceUnit{name: "first", locker: locker, trace: &trace}
+	process := processes.New()
+	process.Add("plain", fn, slice)
+	process.Add("dependent", fn, slice, first, processes.Depend(&TraceUnit{name: "second", locker: locker, trace: &trace}, first, fn, slice))
+	n := 0
+	for result := range process.Start(context.TODO()) {
+		if result.Error != nil {
+			t.Fatalf("%+v", result.Error)
+		}
+		n++
+	}
+	if n != 6 {
+		t.Errorf("expect 6 results, got %d", n)
+	}
+	if fmt.Sprint(trace) != "[first second]" {
+		t.Errorf("unexpected trace: %v", trace)
+	}
+}
@@ -0,0 +1,96 @@
+package processes
+
+import (
+	"context"
+	"time"
+)
+
+// RetryPolicy handles a failed unit again, every failed attempt which is retried is sent as a Result which Retrying is true.
+type RetryPolicy struct {
+	// MaxAttempts is the max number of attempts including the first one, the unit is not retried when it is less than 2.
+	MaxAttempts int
+	// Backoff returns the delay before the attempt, attempt starts from 2, there is no delay when it is nil.
+	Backoff func(attempt int) (delay time.Duration)
+	// Retryable reports whether the error is transient, all errors are retried when it is nil.
+	// Units are never retried after the process is aborted.
+	Retryable func(err error) (ok bool)
+}
+
+// RetryableUnit has its own retry policy which overrides the policy of step, a nil policy disables retries of the unit.
+type RetryableUnit interface {
+	Unit
+	RetryPolicy() (policy *RetryPolicy)
+}
+
+// WithRetry retries failed units of steps by the policy.
+func WithRetry(policy RetryPolicy) Option {
+	return func(options *Options) {
+		options.Retry = &policy
+	}
+}
+
+// ExponentialBackoff doubles the delay from initial for each attempt, and the delay is not greater than max.
+func ExponentialBackoff(initial time.Duration, max time.Duration) func(attempt int) (delay time.Duration) {
+	return func(attempt int) (delay time.Duration) {
+		delay = initial
+		for i := 2; i < attempt && delay < max; i++ {
+			delay = delay * 2
+		}
+		if delay > max {
+			delay = max
+		}
+		return
+	}
+}
+
+// retryPolicy returns the policy of unit, units which are made by Depend use policies of the wrapped units.
+func (step *Step) retryPolicy(unit Unit) (policy *RetryPolicy) {
+	policy = step.retry
+	if dependent, ok := unit.(*dependentUnit); ok {
+		unit = dependent.unit
+	}
+	if retryable, ok := unit.(RetryableUnit); ok {
+		policy = retryable.RetryPolicy()
+	}
+	return
+}
+
+// handle handles the unit until it succeeds or the policy gives up, failed attempts which are retried and starts of attempts are sent to attemptCh.
+func (step *Step) handle(ctx context.Context, unitNo int64, unitNum int64, unit Unit, attemptCh chan<- Result) (result Result) {
+	policy := step.retryPolicy(unit)
+	attempt := 1
+	for {
+		if ctx.Err() != nil {
+			result = step.result(unitNo, unitNum, int64(attempt), nil, ctx.Err())
+			return
+		}
+		begin := time.Now()
+		if step.events {
+			attemptCh <- step.event(UnitStarted, unitNo, unitNum, int64(attempt), begin, 0, nil)
+		}
+		data, err := step.call(ctx, unitNo, unit)
+		result = step.result(unitNo, unitNum, int64(attempt), data, err)
+		result.Duration = time.Since(begin)
+		if err == nil || policy == nil || attempt >= policy.MaxAttempts || ctx.Err() != nil || IsAbortErr(err) || IsPanicErr(err) {
+			return
+		}
+		if policy.Retryable != nil && !policy.Retryable(err) {
+			return
+		}
+		result.Retrying = true
+		attemptCh <- result
+		attempt++
+		if policy.Backoff != nil {
+			if delay := policy.Backoff(attempt); delay > 0 {
+				timer := time.NewTimer(delay)
+				select {
+				case <-ctx.Done():
+					timer.Stop()
+					break
+				case <-timer.C:
+					break
+				}
+			}
+		}
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"github.com/aacfactory/errors"
+	"strings"
+	"time"
 	"unicode/utf8"
 )
 
@@ -17,8 +19,18 @@ type Result struct {
 	StepName string
 	UnitNo   int64
 	UnitNum  int64
+	// Attempt is the number of handling of the unit, it is 0 when the unit was not handled.
+	Attempt int64
+	// Retrying means the attempt failed and the unit will be handled again, it is not the last result of the unit.
+	Retrying bool
 	Data     interface{}
 	Error    error
+	// Event is the kind of the result, it is UnitFinished for results of units.
+	Event Event
+	// Time is when the result was made.
+	Time time.Time
+	// Duration is the time of the attempt of unit, the step or the process which is finished, it is 0 for started events.
+	Duration time.Duration
 }
 
 func (result Result) String() string {
@@ -32,15 +44,36 @@ func (result Result) String() string {
 			status = " failed"
 		}
 	}
+	switch result.Event {
+	case UnitStarted:
+		return fmt.Sprintf("[%d/%d] %s [%d/%d] started", result.StepNo, result.StepNum, result.StepName, result.UnitNo, result.UnitNum)
+	case StepStarted:
+		return fmt.Sprintf("[%d/%d] %s started", result.StepNo, result.StepNum, result.StepName)
+	case StepFinished:
+		return fmt.Sprintf("[%d/%d] %s %s in %s", result.StepNo, result.StepNum, result.StepName, strings.TrimSpace(status), result.Duration)
+	case ProcessFinished:
+		return fmt.Sprintf("process %s in %s", strings.TrimSpace(status), result.Duration)
+	default:
+		break
+	}
+	if result.Retrying {
+		return fmt.Sprintf("[%d/%d] %s [%d/%d] retrying (attempt %d failed)", result.StepNo, result.StepNum, result.StepName, result.UnitNo, result.UnitNum, result.Attempt)
+	}
 	return fmt.Sprintf("[%d/%d] %s [%d/%d] %s", result.StepNo, result.StepNum, result.StepName, result.UnitNo, result.UnitNum, status)
 }
 
 type Step struct {
-	no       int64
-	name     string
-	num      int64
-	units    []Unit
-	resultCh chan<- Result
+	no          int64
+	name        string
+	num         int64
+	units       []Unit
+	loader      func(ctx context.Context) (units []Unit, err error)
+	maxParallel int
+	ordered     bool
+	retry       *RetryPolicy
+	events      bool
+	panicPolicy PanicPolicy
+	resultCh    chan<- Result
 }
 
 func (step *Step) Execute(ctx context.Context) (err error) {
@@ -48,70 +81,289 @@ func (step *Step) Execute(ctx context.Context) (err error) {
 		err = ctx.Err()
 		return
 	}
-	if step.units == nil || len(step.units) == 0 {
-		return
+	// events of step are sent after units are loaded, so they have the number of units of lazy steps
+	begin := time.Now()
+	unitNum := int64(0)
+	if step.events {
+		defer func() {
+			step.resultCh <- step.event(StepFinished, 0, unitNum, 0, time.Now(), time.Since(begin), err)
+		}()
 	}
-	unitNum := int64(len(step.units))
-	stepResultCh := make(chan Result, unitNum)
-	for i, unit := range step.units {
-		unitNo := int64(i + 1)
-		if unit == nil {
+	if step.loader != nil {
+		units, loadErr := step.loader(ctx)
+		if loadErr != nil {
+			unitNum = 1
+			step.started(begin, unitNum)
+			err = errors.Warning("processes: load units failed").WithMeta("step", step.name).WithCause(loadErr)
 			step.resultCh <- Result{
 				StepNo:   step.no,
 				StepNum:  step.num,
 				StepName: step.name,
-				UnitNo:   unitNo,
+				UnitNo:   1,
 				UnitNum:  unitNum,
+				Attempt:  0,
+				Retrying: false,
 				Data:     nil,
-				Error:    errors.Warning("processes: unit is nil").WithMeta("step", step.name),
+				Error:    err,
+				Event:    UnitFinished,
+				Time:     time.Now(),
+				Duration: 0,
 			}
-			continue
+			return
 		}
-		go func(ctx context.Context, unitNo int64, unit Unit, step *Step, stepResultCh chan Result) {
-			if ctx.Err() != nil {
-				stepResultCh <- Result{
-					StepNo:   step.no,
-					StepNum:  step.num,
-					StepName: step.name,
-					UnitNo:   unitNo,
-					UnitNum:  unitNum,
-					Data:     nil,
-					Error:    ctx.Err(),
-				}
-				return
-			}
-			data, unitErr := unit.Handle(ctx)
-			defer func() {
-				_ = recover()
-			}()
-			stepResultCh <- Result{
-				StepNo:   step.no,
-				StepNum:  step.num,
-				StepName: step.name,
-				UnitNo:   unitNo,
-				UnitNum:  unitNum,
-				Data:     data,
-				Error:    unitErr,
-			}
-		}(ctx, unitNo, unit, step, stepResultCh)
+		step.units = units
+	}
+	unitNum = int64(len(step.units))
+	step.started(begin, unitNum)
+	if unitNum == 0 {
+		return
 	}
-	resultErrs := errors.MakeErrors()
+	dependencies, graphErr := step.graph()
+	if graphErr != nil {
+		err = graphErr
+		step.resultCh <- Result{
+			StepNo:   step.no,
+			StepNum:  step.num,
+			StepName: step.name,
+			UnitNo:   1,
+			UnitNum:  unitNum,
+			Attempt:  0,
+			Retrying: false,
+			Data:     nil,
+			Error:    err,
+			Event:    UnitFinished,
+			Time:     time.Now(),
+			Duration: 0,
+		}
+		return
+	}
+	stepResultCh := make(chan Result, unitNum)
+	unitCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go step.dispatch(unitCtx, cancel, unitNum, dependencies, stepResultCh)
+	failures := make([]error, 0, 1)
 	executed := int64(0)
+	// results of ordered step are held until results of previous units are sent, events and retried attempts are held with the unit.
+	pending := make(map[int64][]Result)
+	next := int64(1)
 	for {
 		result, ok := <-stepResultCh
 		if !ok {
 			err = errors.Warning("forg: panic")
 			break
 		}
+		if !result.final() {
+			if step.ordered {
+				pending[result.UnitNo] = append(pending[result.UnitNo], result)
+			} else {
+				step.resultCh <- result
+			}
+			continue
+		}
 		if result.Error != nil {
-			resultErrs.Append(result.Error)
+			failures = append(failures, result.Error)
 		}
-		step.resultCh <- result
 		executed++
+		if step.ordered {
+			pending[result.UnitNo] = append(pending[result.UnitNo], result)
+			for {
+				prev, has := pending[next]
+				if !has || !prev[len(prev)-1].final() {
+					break
+				}
+				delete(pending, next)
+				for _, item := range prev {
+					step.resultCh <- item
+				}
+				next++
+			}
+		} else {
+			step.resultCh <- result
+		}
 		if executed >= unitNum {
 			break
 		}
 	}
-	err = resultErrs.Error()
+	if len(failures) > 0 {
+		err = &stepError{
+			CodeError: errors.Warning(unitsFailedMessage).WithMeta("step", step.name).WithMeta("failed", fmt.Sprintf("%d", len(failures))),
+			failures:  failures,
+		}
+	}
+	return
+}
+
+const (
+	unitsFailedMessage = "processes: units of step failed"
+)
+
+// stepError is the error of step which units failed, errors of units are kept as they are instead of being chained,
+// because they are read by consumers of results at the same time. Contains and Unwrap see errors of units.
+type stepError struct {
+	errors.CodeError
+	failures []error
+}
+
+func (e *stepError) Contains(err error) (has bool) {
+	if e.CodeError.Contains(err) {
+		has = true
+		return
+	}
+	for _, failure := range e.failures {
+		if errors.Map(failure).Contains(err) {
+			has = true
+			return
+		}
+	}
 	return
 }
+
+func (e *stepError) Unwrap() []error {
+	return e.failures
+}
+
+func (e *stepError) Error() string {
+	return e.String()
+}
+
+func (e *stepError) String() string {
+	return fmt.Sprintf("%+v", e)
+}
+
+func (e *stepError) Format(state fmt.State, verb rune) {
+	e.CodeError.Format(state, verb)
+	format := "%v"
+	if state.Flag('+') {
+		format = "%+v"
+	}
+	for _, failure := range e.failures {
+		_, _ = fmt.Fprintf(state, "\n"+format, failure)
+	}
+}
+
+// panicked reports whether the step failed only by panicked units and units which depend on them.
+func (e *stepError) panicked() (ok bool) {
+	for _, failure := range e.failures {
+		if IsPanicErr(failure) {
+			ok = true
+			continue
+		}
+		if !IsDependencyFailedErr(failure) {
+			ok = false
+			return
+		}
+	}
+	return
+}
+
+func (step *Step) result(unitNo int64, unitNum int64, attempt int64, data interface{}, err error) Result {
+	return Result{
+		StepNo:   step.no,
+		StepNum:  step.num,
+		StepName: step.name,
+		UnitNo:   unitNo,
+		UnitNum:  unitNum,
+		Attempt:  attempt,
+		Retrying: false,
+		Data:     data,
+		Error:    err,
+		Event:    UnitFinished,
+		Time:     time.Now(),
+		Duration: 0,
+	}
+}
+
+// dispatch handles units in goroutines after their dependencies are succeeded,
+// the number of running units is limited by maxParallel, and units which depend on failed units are skipped.
+// Units are canceled by cancel when one of them panicked and the panic policy is StopOnPanic.
+func (step *Step) dispatch(ctx context.Context, cancel context.CancelFunc, unitNum int64, dependencies [][]int, stepResultCh chan Result) {
+	remaining := make([]int, len(step.units))
+	dependents := make([][]int, len(step.units))
+	for i, deps := range dependencies {
+		remaining[i] = len(deps)
+		for _, dep := range deps {
+			dependents[dep] = append(dependents[dep], i)
+		}
+	}
+	ready := make([]int, 0, len(step.units))
+	for i, n := range remaining {
+		if n == 0 {
+			ready = append(ready, i)
+		}
+	}
+	finished := make([]bool, len(step.units))
+	// skip marks dependents of the unit finished by the failure of it, errors of results are not shared,
+	// because consumers may read them while others are handled.
+	var skip func(i int, cause error)
+	skip = func(i int, cause error) {
+		for _, dependent := range dependents[i] {
+			if finished[dependent] {
+				continue
+			}
+			finished[dependent] = true
+			var err error = errors.Warning(dependencyFailedMessage).
+				WithMeta("step", step.name).WithMeta("dependency", fmt.Sprintf("%d", i+1)).WithMeta("cause", cause.Error())
+			if ctx.Err() != nil {
+				err = ctx.Err()
+			}
+			stepResultCh <- step.result(int64(dependent+1), unitNum, 0, nil, err)
+			skip(dependent, cause)
+		}
+	}
+	doneCh := make(chan Result, len(step.units))
+	running := 0
+	for {
+		for len(ready) > 0 && (step.maxParallel <= 0 || running < step.maxParallel) {
+			i := ready[0]
+			ready = ready[1:]
+			if finished[i] {
+				continue
+			}
+			unitNo := int64(i + 1)
+			unit := step.units[i]
+			if unit == nil || ctx.Err() != nil {
+				var err error = errors.Warning("processes: unit is nil").WithMeta("step", step.name)
+				if unit != nil {
+					err = ctx.Err()
+				}
+				finished[i] = true
+				stepResultCh <- step.result(unitNo, unitNum, 0, nil, err)
+				skip(i, err)
+				continue
+			}
+			running++
+			go func(ctx context.Context, unitNo int64, unit Unit, step *Step, doneCh chan Result) {
+				if ctx.Err() != nil {
+					doneCh <- step.result(unitNo, unitNum, 0, nil, ctx.Err())
+					return
+				}
+				doneCh <- step.handle(ctx, unitNo, unitNum, unit, doneCh)
+			}(ctx, unitNo, unit, step, doneCh)
+		}
+		if running == 0 {
+			return
+		}
+		result := <-doneCh
+		if !result.final() {
+			stepResultCh <- result
+			continue
+		}
+		running--
+		i := int(result.UnitNo - 1)
+		finished[i] = true
+		stepResultCh <- result
+		if result.Error != nil {
+			if step.panicPolicy == StopOnPanic && IsPanicErr(result.Error) {
+				cancel()
+			}
+			skip(i, result.Error)
+			continue
+		}
+		for _, dependent := range dependents[i] {
+			remaining[dependent]--
+			if remaining[dependent] == 0 {
+				ready = append(ready, dependent)
+			}
+		}
+	}
+}
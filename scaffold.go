@@ -0,0 +1,254 @@
+package forg
+
+import (
+	"context"
+	"github.com/aacfactory/errors"
+	"github.com/aacfactory/forg/files"
+	"github.com/aacfactory/forg/module"
+	"github.com/aacfactory/forg/scaffolds"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+// NewService creates doc.go of a new service in modules dir, it refuses names of existing services or dirs.
+func (project *Project) NewService(ctx context.Context, service *scaffolds.Service) (filename string, err error) {
+	validErr := service.Validate()
+	if validErr != nil {
+		err = errors.Warning("forg: new service failed").WithCause(validErr)
+		return
+	}
+	parseErr := project.Mod.Parse(ctx)
+	if parseErr != nil {
+		err = errors.Warning("forg: new service failed").WithCause(parseErr)
+		return
+	}
+	services, servicesErr := project.Mod.Services()
+	if servicesErr != nil {
+		err = errors.Warning("forg: new service failed").WithCause(servicesErr)
+		return
+	}
+	for _, existing := range services {
+		if existing.Name == service.Name {
+			err = errors.Warning("forg: new service failed").WithCause(errors.Warning("service was existed")).
+				WithMeta("service", service.Name).WithMeta("dir", existing.Dir)
+			return
+		}
+	}
+	dir := filepath.Join(project.Mod.Dir, project.Config.Modules, service.Name)
+	if files.Exist(project.fsys, dir) {
+		err = errors.Warning("forg: new service failed").WithCause(errors.Warning("dir was existed")).
+			WithMeta("service", service.Name).WithMeta("dir", filepath.ToSlash(dir))
+		return
+	}
+	content, renderErr := service.Doc()
+	if renderErr != nil {
+		err = errors.Warning("forg: new service failed").WithCause(renderErr)
+		return
+	}
+	filename = filepath.ToSlash(filepath.Join(dir, "doc.go"))
+	writeErr := project.writer.WriteFile(filename, content)
+	if writeErr != nil {
+		err = errors.Warning("forg: new service failed").WithCause(writeErr).WithMeta("file", filename)
+		filename = ""
+		return
+	}
+	project.Mod.Invalidate(project.Mod.Path + "/" + project.Config.Modules + "/" + service.Name)
+	return
+}
+
+// NewFunction creates the source file of a new function with its param and result in the service,
+// it refuses names of existing functions, files and top level declarations of the service package.
+func (project *Project) NewFunction(ctx context.Context, serviceName string, function *scaffolds.Function) (filename string, err error) {
+	parseErr := project.Mod.Parse(ctx)
+	if parseErr != nil {
+		err = errors.Warning("forg: new function failed").WithCause(parseErr)
+		return
+	}
+	services, servicesErr := project.Mod.Services()
+	if servicesErr != nil {
+		err = errors.Warning("forg: new function failed").WithCause(servicesErr)
+		return
+	}
+	serviceName = strings.ToLower(serviceName)
+	var service *module.Service
+	for _, existing := range services {
+		if existing.Name == serviceName {
+			service = existing
+			break
+		}
+	}
+	if service == nil {
+		err = errors.Warning("forg: new function failed").WithCause(errors.Warning("service was not found")).WithMeta("service", serviceName)
+		return
+	}
+	function.Package = service.PathIdent
+	for _, existing := range service.Functions {
+		if existing.Name() == function.Name || existing.Ident == function.Ident {
+			err = errors.Warning("forg: new function failed").WithCause(errors.Warning("function was existed")).
+				WithMeta("service", service.Name).WithMeta("function", function.Name).WithMeta("file", existing.Filename())
+			return
+		}
+	}
+	filename = filepath.ToSlash(filepath.Join(service.Dir, function.Filename()))
+	if files.Exist(project.fsys, filename) {
+		err = errors.Warning("forg: new function failed").WithCause(errors.Warning("file was existed")).
+			WithMeta("service", service.Name).WithMeta("file", filename)
+		filename = ""
+		return
+	}
+	declared, declaredErr := declaredIdents(project.fsys, service.Dir)
+	if declaredErr != nil {
+		err = errors.Warning("forg: new function failed").WithCause(declaredErr).WithMeta("service", service.Name)
+		filename = ""
+		return
+	}
+	for _, ident := range function.Idents() {
+		if declaredFilename, has := declared[ident]; has {
+			err = errors.Warning("forg: new function failed").WithCause(errors.Warning("identifier was declared")).
+				WithMeta("service", service.Name).WithMeta("ident", ident).WithMeta("file", declaredFilename)
+			filename = ""
+			return
+		}
+	}
+	content, renderErr := function.Source()
+	if renderErr != nil {
+		err = errors.Warning("forg: new function failed").WithCause(renderErr)
+		filename = ""
+		return
+	}
+	writeErr := project.writer.WriteFile(filename, content)
+	if writeErr != nil {
+		err = errors.Warning("forg: new function failed").WithCause(writeErr).WithMeta("file", filename)
+		filename = ""
+		return
+	}
+	project.Mod.Invalidate(service.Path)
+	return
+}
+
+// declaredIdents returns top level identifiers of go files in dir, values are filenames.
+func declaredIdents(fsys fs.FS, dir string) (idents map[string]string, err error) {
+	entries, readErr := files.ReadDir(fsys, dir)
+	if readErr != nil {
+		err = errors.Warning("forg: read dir failed").WithCause(readErr).WithMeta("dir", dir)
+		return
+	}
+	idents = make(map[string]string)
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".go" {
+			continue
+		}
+		filename := filepath.ToSlash(filepath.Join(dir, entry.Name()))
+		src, readFileErr := files.ReadFile(fsys, filename)
+		if readFileErr != nil {
+			err = errors.Warning("forg: read source failed").WithCause(readFileErr).WithMeta("file", filename)
+			return
+		}
+		file, parseErr := parser.ParseFile(token.NewFileSet(), filename, src, parser.SkipObjectResolution)
+		if parseErr != nil {
+			err = errors.Warning("forg: parse source failed").WithCause(parseErr).WithMeta("file", filename)
+			return
+		}
+		for _, decl := range file.Decls {
+			switch d := decl.(type) {
+			case *ast.FuncDecl:
+				if d.Recv == nil {
+					idents[d.Name.Name] = filename
+				}
+				break
+			case *ast.GenDecl:
+				for _, spec := range d.Specs {
+					switch s := spec.(type) {
+					case *ast.TypeSpec:
+						idents[s.Name.Name] = filename
+						break
+					case *ast.ValueSpec:
+						for _, name := range s.Names {
+							idents[name.Name] = filename
+						}
+						break
+					}
+				}
+				break
+			}
+		}
+	}
+	return
+}
+
+// Init writes the skeleton of a project into dir, it refuses to overwrite any existing file.
+// Existing files are checked in the file system of WithFS, and files are written by the writer of WithWriter,
+// other options are not used. Code files are not generated, load the project and call Coding after that.
+func Init(ctx context.Context, dir string, project *scaffolds.Project, options ...Option) (created []string, err error) {
+	if ctx.Err() != nil {
+		err = errors.Warning("forg: init project failed").WithCause(ctx.Err())
+		return
+	}
+	opt := &Options{
+		Workspace:  "",
+		DryRun:     false,
+		Packages:   false,
+		GOOS:       "",
+		GOARCH:     "",
+		Tags:       nil,
+		Cache:      "",
+		FS:         nil,
+		Writer:     nil,
+		Parallel:   0,
+		Retry:      nil,
+		Events:     false,
+		ConfigFile: "",
+		Modules:    "",
+		Output:     "",
+		Package:    "",
+		Enables:    nil,
+		Generators: nil,
+	}
+	for _, option := range options {
+		optionErr := option(opt)
+		if optionErr != nil {
+			err = errors.Warning("forg: init project failed").WithCause(optionErr)
+			return
+		}
+	}
+	fsys := opt.FS
+	if fsys == nil {
+		fsys = files.Disk()
+	}
+	writer := opt.Writer
+	if writer == nil {
+		writer = files.DiskWriter()
+	}
+	validErr := project.Config.Validate()
+	if validErr != nil {
+		err = errors.Warning("forg: init project failed").WithCause(validErr)
+		return
+	}
+	skeleton, renderErr := project.Files()
+	if renderErr != nil {
+		err = errors.Warning("forg: init project failed").WithCause(renderErr)
+		return
+	}
+	for _, file := range skeleton {
+		filename := filepath.Join(dir, filepath.FromSlash(file.Name))
+		if files.Exist(fsys, filename) {
+			err = errors.Warning("forg: init project failed").WithCause(errors.Warning("file was existed")).WithMeta("file", filepath.ToSlash(filename))
+			return
+		}
+	}
+	created = make([]string, 0, len(skeleton))
+	for _, file := range skeleton {
+		filename := filepath.Join(dir, filepath.FromSlash(file.Name))
+		writeErr := writer.WriteFile(filename, file.Content)
+		if writeErr != nil {
+			err = errors.Warning("forg: init project failed").WithCause(writeErr).WithMeta("file", filepath.ToSlash(filename))
+			return
+		}
+		created = append(created, filepath.ToSlash(filename))
+	}
+	return
+}
@@ -0,0 +1,131 @@
+package forg_test
+
+import (
+	"context"
+	"github.com/aacfactory/forg"
+	"github.com/aacfactory/forg/scaffolds"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestProject_NewService(t *testing.T) {
+	dir := copyProject(t, filepath.Join("testdata", "standalone"))
+	if err := os.WriteFile(filepath.Join(dir, "modules", "users", "list.go"), []byte("package users\n\ntype ListParam struct{}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	p, loadErr := forg.Load(dir)
+	if loadErr != nil {
+		t.Fatalf("%+v", loadErr)
+	}
+	ctx := context.TODO()
+	if _, err := p.NewService(ctx, &scaffolds.Service{Name: "users"}); err == nil {
+		t.Error("existing service must be refused")
+	}
+	if _, err := p.NewService(ctx, &scaffolds.Service{Name: "posts", Title: "Posts"}); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	function, _ := scaffolds.NewFunction("", "getPost")
+	if _, err := p.NewFunction(ctx, "posts", function); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if _, err := p.NewFunction(ctx, "posts", function); err == nil {
+		t.Error("existing function must be refused")
+	}
+	collided, _ := scaffolds.NewFunction("", "list")
+	if _, err := p.NewFunction(ctx, "users", collided); err == nil {
+		t.Error("function which declares existing identifier must be refused")
+	}
+	model, inspectErr := p.Inspect(ctx)
+	if inspectErr != nil {
+		t.Fatalf("%+v", inspectErr)
+	}
+	if len(model.Services) != 2 || model.Services[0].Name != "posts" || len(model.Services[0].Functions) != 1 {
+		t.Fatalf("unexpected model: %+v", model.Services)
+	}
+	if fn := model.Services[0].Functions[0]; fn.Param.Type.Name != "GetPostParam" || fn.Result.Type.Name != "GetPostResult" {
+		t.Errorf("unexpected function: %+v", fn)
+	}
+}
+
+func TestInit(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "hello")
+	project, _ := scaffolds.NewProject("example.com/hello")
+	created, initErr := forg.Init(context.TODO(), dir, project)
+	if initErr != nil {
+		t.Fatalf("%+v", initErr)
+	}
+	if len(created) != 8 {
+		t.Errorf("unexpected created files: %v", created)
+	}
+	if _, initErr = forg.Init(context.TODO(), dir, project); initErr == nil {
+		t.Error("existing files must not be overwritten")
+	}
+	p, loadErr := forg.Load(dir)
+	if loadErr != nil {
+		t.Fatalf("%+v", loadErr)
+	}
+	model, inspectErr := p.Inspect(context.TODO())
+	if inspectErr != nil {
+		t.Fatalf("%+v", inspectErr)
+	}
+	if len(model.Services) != 1 || model.Services[0].Name != "users" || len(model.Services[0].Components) != 1 || len(model.Services[0].Functions) != 1 {
+		t.Errorf("unexpected model: %+v", model.Services)
+	}
+}
+
+func TestInit_Vet(t *testing.T) {
+	if testing.Short() {
+		t.Skip("go vet of scaffolded project is skipped in short mode")
+	}
+	goCommand, lookErr := exec.LookPath("go")
+	if lookErr != nil {
+		t.Skip("go command was not found")
+	}
+	// the scaffolded project and its code files are vetted against the stub of fns which has the api used by them
+	stub, absErr := filepath.Abs(filepath.Join("testdata", "fns"))
+	if absErr != nil {
+		t.Fatal(absErr)
+	}
+	dir := filepath.Join(t.TempDir(), "hello")
+	project, _ := scaffolds.NewProject("example.com/hello")
+	if _, initErr := forg.Init(context.TODO(), dir, project); initErr != nil {
+		t.Fatalf("%+v", initErr)
+	}
+	mod, readErr := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if readErr != nil {
+		t.Fatal(readErr)
+	}
+	mod = append(mod, []byte("\nreplace "+scaffolds.FnsPath+" => "+filepath.ToSlash(stub)+"\n")...)
+	if err := os.WriteFile(filepath.Join(dir, "go.mod"), mod, 0644); err != nil {
+		t.Fatal(err)
+	}
+	sum, readSumErr := os.ReadFile(filepath.Join("testdata", "standalone", "go.sum"))
+	if readSumErr != nil {
+		t.Fatal(readSumErr)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "go.sum"), sum, 0644); err != nil {
+		t.Fatal(err)
+	}
+	p, loadErr := forg.Load(dir)
+	if loadErr != nil {
+		t.Fatalf("%+v", loadErr)
+	}
+	process, codingErr := p.Coding(context.TODO())
+	if codingErr != nil {
+		t.Fatalf("%+v", codingErr)
+	}
+	for result := range process.Start(context.TODO()) {
+		if result.Error != nil {
+			t.Fatalf("%+v", result.Error)
+		}
+	}
+	cmd := exec.Command(goCommand, "vet", "./...")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOPROXY=off", "GOSUMDB=off", "GOWORK=off", "GOTOOLCHAIN=local")
+	out, vetErr := cmd.CombinedOutput()
+	if vetErr != nil {
+		t.Fatalf("go vet of scaffolded project failed: %v\n%s", vetErr, out)
+	}
+}
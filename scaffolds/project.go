@@ -0,0 +1,136 @@
+package scaffolds
+
+import (
+	"bytes"
+	"github.com/aacfactory/errors"
+	"github.com/aacfactory/forg/configs"
+	"golang.org/x/mod/module"
+	"path"
+	"regexp"
+	"runtime"
+)
+
+const (
+	FnsPath    = "github.com/aacfactory/fns"
+	ErrorsPath = "github.com/aacfactory/errors"
+	// DefaultFnsVersion and DefaultErrorsVersion are pinned, so a project can be created offline.
+	DefaultFnsVersion    = "v1.0.0"
+	DefaultErrorsVersion = "v1.13.4"
+	defaultGoVersion     = "1.20"
+)
+
+var (
+	goVersionPattern = regexp.MustCompile(`^go(\d+\.\d+)`)
+)
+
+type Require struct {
+	Path    string
+	Version string
+}
+
+type File struct {
+	// Name is the slash separated filename relative to project dir.
+	Name    string
+	Content []byte
+}
+
+// Project is the skeleton of a fns project, it contains a sample service with a function and a component.
+type Project struct {
+	Path      string
+	Name      string
+	GoVersion string
+	Requires  []Require
+	Config    *configs.Config
+}
+
+func NewProject(modulePath string) (project *Project, err error) {
+	checkErr := module.CheckPath(modulePath)
+	if checkErr != nil {
+		err = errors.Warning("forg: module path is invalid").WithCause(checkErr).WithMeta("path", modulePath)
+		return
+	}
+	goVersion := defaultGoVersion
+	if matched := goVersionPattern.FindStringSubmatch(runtime.Version()); len(matched) == 2 {
+		goVersion = matched[1]
+	}
+	project = &Project{
+		Path:      modulePath,
+		Name:      path.Base(modulePath),
+		GoVersion: goVersion,
+		Requires: []Require{
+			{Path: ErrorsPath, Version: DefaultErrorsVersion},
+			{Path: FnsPath, Version: DefaultFnsVersion},
+		},
+		Config: configs.Default(),
+	}
+	return
+}
+
+// Require sets the version of a required module, or adds it.
+func (project *Project) Require(path string, version string) {
+	for i, require := range project.Requires {
+		if require.Path == path {
+			project.Requires[i].Version = version
+			return
+		}
+	}
+	project.Requires = append(project.Requires, Require{Path: path, Version: version})
+}
+
+// Files renders all files of the project in creating order.
+func (project *Project) Files() (files []*File, err error) {
+	modules := project.Config.Modules
+	service := &Service{
+		Name:        "users",
+		Title:       "Users",
+		Description: "Sample service",
+		Internal:    false,
+	}
+	function, _ := NewFunction(service.Name, "get")
+	function.Title = "Get user"
+	function.Description = "Sample function"
+	files = make([]*File, 0, 8)
+	items := []struct {
+		name     string
+		template string
+		data     interface{}
+		source   bool
+	}{
+		{name: "go.mod", template: "project_go_mod.tmpl", data: project, source: false},
+		{name: "main.go", template: "project_main.tmpl", data: project, source: true},
+		{name: "forg.yaml", template: "project_forg_yaml.tmpl", data: project, source: false},
+		{name: "configs/fns.yaml", template: "project_fns_yaml.tmpl", data: project, source: false},
+		{name: path.Join(modules, "services.go"), template: "project_services.tmpl", data: project, source: true},
+		{name: path.Join(modules, service.Name, "doc.go"), template: "service_doc.tmpl", data: service, source: true},
+		{name: path.Join(modules, service.Name, function.Filename()), template: "function.tmpl", data: function, source: true},
+		{name: path.Join(modules, service.Name, "components", "store.go"), template: "project_component.tmpl", data: project, source: true},
+	}
+	for _, item := range items {
+		var content []byte
+		if item.source {
+			content, err = render(item.template, item.data)
+		} else {
+			content, err = renderText(item.template, item.data)
+		}
+		if err != nil {
+			files = nil
+			return
+		}
+		files = append(files, &File{
+			Name:    item.name,
+			Content: content,
+		})
+	}
+	return
+}
+
+func renderText(name string, data interface{}) (content []byte, err error) {
+	buf := bytes.NewBuffer(make([]byte, 0, 512))
+	executeErr := templates.ExecuteTemplate(buf, name, data)
+	if executeErr != nil {
+		err = errors.Warning("forg: render scaffold failed").WithMeta("template", name).WithCause(executeErr)
+		return
+	}
+	content = buf.Bytes()
+	return
+}
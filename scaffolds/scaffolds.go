@@ -0,0 +1,120 @@
+package scaffolds
+
+import (
+	"embed"
+	"github.com/aacfactory/cases"
+	"github.com/aacfactory/errors"
+	"go/format"
+	"go/token"
+	"strings"
+	"text/template"
+)
+
+//go:embed templates/*.tmpl
+var templatesFS embed.FS
+
+var templates = template.Must(template.ParseFS(templatesFS, "templates/*.tmpl"))
+
+type Service struct {
+	Name        string
+	Title       string
+	Description string
+	Internal    bool
+}
+
+// Validate checks the name, which is the dir name, the package name and the service name.
+func (service *Service) Validate() (err error) {
+	if !token.IsIdentifier(service.Name) || token.IsKeyword(service.Name) || strings.ToLower(service.Name) != service.Name {
+		err = errors.Warning("forg: service name must be a lower case go package name").WithMeta("name", service.Name)
+		return
+	}
+	if strings.ContainsAny(service.Title+service.Description, "\r\n") {
+		err = errors.Warning("forg: title and description of service must be one line").WithMeta("name", service.Name)
+		return
+	}
+	return
+}
+
+// Doc renders doc.go of service.
+func (service *Service) Doc() (content []byte, err error) {
+	if service.Title == "" {
+		service.Title = service.Name
+	}
+	content, err = render("service_doc.tmpl", service)
+	return
+}
+
+type Function struct {
+	Package       string
+	Name          string
+	Ident         string
+	Param         string
+	Result        string
+	Title         string
+	Description   string
+	Internal      bool
+	Authorization bool
+}
+
+// NewFunction makes a function named by a lower camel name, such as getUser,
+// its ident is the name, param and result are GetUserParam and GetUserResult.
+func NewFunction(pkg string, name string) (function *Function, err error) {
+	if !token.IsIdentifier(name) || token.IsKeyword(name) {
+		err = errors.Warning("forg: function name must be a go identifier").WithMeta("name", name)
+		return
+	}
+	atoms, parseErr := cases.LowerCamel().Parse(name)
+	if parseErr != nil || len(atoms) == 0 || cases.LowerCamel().Format(atoms) != name {
+		err = errors.Warning("forg: function name must be lower camel case").WithMeta("name", name)
+		return
+	}
+	camel := cases.Camel().Format(atoms)
+	function = &Function{
+		Package:       pkg,
+		Name:          name,
+		Ident:         name,
+		Param:         camel + "Param",
+		Result:        camel + "Result",
+		Title:         name,
+		Description:   "",
+		Internal:      false,
+		Authorization: false,
+	}
+	return
+}
+
+// Filename returns snake case go filename of function, such as get_user.go.
+func (function *Function) Filename() (filename string) {
+	atoms, _ := cases.LowerCamel().Parse(function.Name)
+	filename = cases.Snake().Format(atoms) + ".go"
+	return
+}
+
+// Idents returns top level identifiers which are declared by the function file.
+func (function *Function) Idents() (idents []string) {
+	idents = []string{function.Ident, function.Param, function.Result}
+	return
+}
+
+func (function *Function) Source() (content []byte, err error) {
+	if strings.ContainsAny(function.Title+function.Description, "\r\n") {
+		err = errors.Warning("forg: title and description of function must be one line").WithMeta("name", function.Name)
+		return
+	}
+	content, err = render("function.tmpl", function)
+	return
+}
+
+// render renders go source and formats it.
+func render(name string, data interface{}) (content []byte, err error) {
+	content, err = renderText(name, data)
+	if err != nil {
+		return
+	}
+	content, err = format.Source(content)
+	if err != nil {
+		err = errors.Warning("forg: render scaffold failed").WithMeta("template", name).WithCause(err)
+		return
+	}
+	return
+}
@@ -0,0 +1,84 @@
+package scaffolds_test
+
+import (
+	"github.com/aacfactory/forg/scaffolds"
+	"strings"
+	"testing"
+)
+
+func TestNewFunction(t *testing.T) {
+	function, err := scaffolds.NewFunction("users", "getUser")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	if function.Param != "GetUserParam" || function.Result != "GetUserResult" || function.Filename() != "get_user.go" {
+		t.Errorf("unexpected function: %+v %s", function, function.Filename())
+	}
+	content, renderErr := function.Source()
+	if renderErr != nil {
+		t.Fatalf("%+v", renderErr)
+	}
+	if !strings.Contains(string(content), "func getUser(ctx context.Context, param GetUserParam) (result GetUserResult, err errors.CodeError) {") {
+		t.Errorf("unexpected source:\n%s", content)
+	}
+	for _, name := range []string{"GetUser", "get-user", "func", ""} {
+		if _, err = scaffolds.NewFunction("users", name); err == nil {
+			t.Errorf("%q must be invalid", name)
+		}
+	}
+}
+
+func TestService_Doc(t *testing.T) {
+	service := &scaffolds.Service{
+		Name:        "users",
+		Title:       "Users",
+		Description: "users service",
+		Internal:    true,
+	}
+	if err := service.Validate(); err != nil {
+		t.Fatalf("%+v", err)
+	}
+	content, err := service.Doc()
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	expected := "// Package users\n// @service users\n// @title Users\n// @description users service\n// @internal\npackage users\n"
+	if string(content) != expected {
+		t.Errorf("unexpected doc:\n%s", content)
+	}
+	for _, name := range []string{"Users", "user-s", "type"} {
+		if err = (&scaffolds.Service{Name: name}).Validate(); err == nil {
+			t.Errorf("%q must be invalid", name)
+		}
+	}
+}
+
+func TestProject_Files(t *testing.T) {
+	if _, err := scaffolds.NewProject("not a path"); err == nil {
+		t.Error("invalid module path must be refused")
+	}
+	project, err := scaffolds.NewProject("example.com/hello")
+	if err != nil {
+		t.Fatalf("%+v", err)
+	}
+	project.Require(scaffolds.FnsPath, "v1.2.3")
+	files, filesErr := project.Files()
+	if filesErr != nil {
+		t.Fatalf("%+v", filesErr)
+	}
+	names := make(map[string]string)
+	for _, file := range files {
+		names[file.Name] = string(file.Content)
+	}
+	for _, name := range []string{"go.mod", "main.go", "forg.yaml", "configs/fns.yaml", "modules/services.go", "modules/users/doc.go", "modules/users/get.go", "modules/users/components/store.go"} {
+		if _, has := names[name]; !has {
+			t.Errorf("%s was not rendered", name)
+		}
+	}
+	if !strings.Contains(names["go.mod"], "module example.com/hello\n") || !strings.Contains(names["go.mod"], "\tgithub.com/aacfactory/fns v1.2.3\n") {
+		t.Errorf("unexpected go.mod:\n%s", names["go.mod"])
+	}
+	if !strings.Contains(names["main.go"], "app.Deploy(modules.Services()...)") {
+		t.Errorf("unexpected main.go:\n%s", names["main.go"])
+	}
+}
@@ -0,0 +1,29 @@
+// Package fns is a stub of github.com/aacfactory/fns, it has the api which is used by scaffolds and generated code.
+package fns
+
+import (
+	"context"
+	"github.com/aacfactory/fns/service"
+)
+
+type Options struct {
+	Version string
+}
+
+type Option func(options *Options)
+
+func Version(version string) Option {
+	return func(options *Options) {
+		options.Version = version
+	}
+}
+
+type Application interface {
+	Deploy(services ...service.Service) (err error)
+	Run(ctx context.Context) (err error)
+	Sync()
+}
+
+func New(options ...Option) (app Application) {
+	return
+}
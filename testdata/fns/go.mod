@@ -0,0 +1,5 @@
+module github.com/aacfactory/fns
+
+go 1.20
+
+require github.com/aacfactory/errors v1.13.4
@@ -0,0 +1,48 @@
+package documents
+
+type Element struct {
+	Path  string
+	Name  string
+	Title string
+}
+
+func (element *Element) SetTitle(title string) *Element {
+	element.Title = title
+	return element
+}
+
+func Struct(path string, name string) *Element {
+	return &Element{
+		Path:  path,
+		Name:  name,
+		Title: "",
+	}
+}
+
+type FnError struct {
+	Name         string
+	Descriptions map[string]string
+}
+
+type Service struct {
+	name        string
+	description string
+	version     string
+}
+
+func NewService(name string, description string, version string) (doc *Service) {
+	doc = &Service{
+		name:        name,
+		description: description,
+		version:     version,
+	}
+	return
+}
+
+func (doc *Service) Name() (name string) {
+	name = doc.name
+	return
+}
+
+func (doc *Service) AddFn(name string, title string, description string, authorization bool, deprecated bool, param *Element, result *Element, errs []FnError) {
+}
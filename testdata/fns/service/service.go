@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"github.com/aacfactory/errors"
+)
+
+type ComponentOptions struct {
+	AppVersion string
+}
+
+type Component interface {
+	Name() (name string)
+	Build(options ComponentOptions) (err error)
+	Close()
+}
+
+type Document interface {
+	Name() (name string)
+}
+
+type Argument interface {
+	As(v interface{}) (err errors.CodeError)
+}
+
+func NewArgument(v interface{}) (argument Argument) {
+	return
+}
+
+type Service interface {
+	Name() (name string)
+	Internal() (ok bool)
+	Components() (components []Component)
+	Document() (doc Document)
+	Handle(ctx context.Context, fn string, argument Argument) (v interface{}, err errors.CodeError)
+	Close()
+}
+
+type Abstract struct {
+	name       string
+	internal   bool
+	components []Component
+}
+
+func NewAbstract(name string, internal bool, components ...Component) (v Abstract) {
+	v = Abstract{
+		name:       name,
+		internal:   internal,
+		components: components,
+	}
+	return
+}
+
+func (abstract Abstract) Name() (name string) {
+	name = abstract.name
+	return
+}
+
+func (abstract Abstract) Internal() (ok bool) {
+	ok = abstract.internal
+	return
+}
+
+func (abstract Abstract) Components() (components []Component) {
+	components = abstract.components
+	return
+}
+
+func (abstract Abstract) AppVersion() (version string) {
+	return
+}
+
+func (abstract Abstract) Close() {
+}
+
+type Request interface {
+	Service() (name string)
+	Fn() (name string)
+}
+
+type RequestOption func(options *RequestOptions)
+
+type RequestOptions struct {
+	Internal bool
+}
+
+func WithInternalRequest() RequestOption {
+	return func(options *RequestOptions) {
+		options.Internal = true
+	}
+}
+
+func NewRequest(ctx context.Context, service string, fn string, argument Argument, options ...RequestOption) (request Request) {
+	return
+}
+
+type FutureResult interface {
+	Exist() (ok bool)
+	Scan(v interface{}) (err error)
+}
+
+type Endpoint interface {
+	RequestSync(ctx context.Context, r Request) (result FutureResult, err errors.CodeError)
+}
+
+func GetEndpoint(ctx context.Context, name string) (endpoint Endpoint, has bool) {
+	return
+}
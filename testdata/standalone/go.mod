@@ -0,0 +1,13 @@
+module example.com/standalone
+
+go 1.20
+
+require github.com/aacfactory/errors v1.13.4
+
+require (
+	github.com/rs/xid v1.4.0 // indirect
+	github.com/tidwall/gjson v1.14.4 // indirect
+	github.com/tidwall/match v1.1.1 // indirect
+	github.com/tidwall/pretty v1.2.1 // indirect
+	github.com/valyala/bytebufferpool v1.0.0 // indirect
+)
@@ -0,0 +1,5 @@
+package components
+
+// Store
+// @component
+type Store struct{}
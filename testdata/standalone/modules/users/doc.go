@@ -0,0 +1,5 @@
+// Package users
+// @service users
+// @title Users
+// @description Users service
+package users
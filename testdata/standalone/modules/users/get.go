@@ -0,0 +1,35 @@
+package users
+
+import (
+	"context"
+	"github.com/aacfactory/errors"
+)
+
+// GetParam
+// @title get param
+type GetParam struct {
+	// Id
+	// @title id
+	Id string `json:"id" validate:"required" message:"id is required"`
+}
+
+// User
+// @title user
+type User struct {
+	Id   string   `json:"id"`
+	Name string   `json:"name"`
+	Tags []string `json:"tags"`
+}
+
+// get
+// @fn get
+// @title Get user
+// @timeout 1s
+// @errors >>>
+// + users_get_failed
+//   - zh: 失败
+//
+// <<<
+func get(ctx context.Context, param GetParam) (result User, err errors.CodeError) {
+	return
+}
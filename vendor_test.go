@@ -0,0 +1,61 @@
+package forg_test
+
+import (
+	"context"
+	"github.com/aacfactory/forg"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoad_Vendor(t *testing.T) {
+	dir := copyProject(t, filepath.Join("testdata", "standalone"))
+	writes := map[string]string{
+		"go.mod":                            "module example.com/standalone\n\ngo 1.20\n\nrequire (\n\tgithub.com/aacfactory/errors v1.13.4\n\texample.com/lib v1.0.0\n)\n",
+		"vendor/modules.txt":                "# example.com/lib v1.0.0\n## explicit; go 1.20\nexample.com/lib\nexample.com/lib/pages\n# github.com/aacfactory/errors v1.13.4\n## explicit; go 1.18\ngithub.com/aacfactory/errors\n",
+		"vendor/example.com/lib/lib.go":     "package lib\n\nimport \"example.com/lib/pages\"\n\n// Query\n// @title query\ntype Query struct {\n\tPage pages.Page `json:\"page\"`\n}\n",
+		"vendor/example.com/lib/pages/p.go": "package pages\n\ntype Page struct {\n\tNo int `json:\"no\"`\n}\n",
+		"modules/users/find.go":             "package users\n\nimport (\n\t\"context\"\n\t\"example.com/lib\"\n\t\"github.com/aacfactory/errors\"\n)\n\n// find\n// @fn find\n// @title Find user\nfunc find(ctx context.Context, param lib.Query) (result User, err errors.CodeError) {\n\treturn\n}\n",
+	}
+	for name, content := range writes {
+		filename := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filename, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// empty module cache
+	t.Setenv("GOENV", "off")
+	t.Setenv("GOMODCACHE", "")
+	t.Setenv("GOPATH", t.TempDir())
+	t.Setenv("GOFLAGS", "")
+	p, loadErr := forg.Load(dir)
+	if loadErr != nil {
+		t.Fatalf("%+v", loadErr)
+	}
+	model, inspectErr := p.Inspect(context.TODO())
+	if inspectErr != nil {
+		t.Fatalf("%+v", inspectErr)
+	}
+	var param *forg.TypeModel
+	for _, fn := range model.Services[0].Functions {
+		if fn.Name == "find" && fn.Param != nil {
+			param = fn.Param.Type
+		}
+	}
+	if param == nil || param.Path != "example.com/lib" || param.Name != "Query" || param.Annotations["title"] != "query" {
+		t.Fatalf("unexpected param: %+v", param)
+	}
+	if page := param.Elements[0].Elements[0]; page.Path != "example.com/lib/pages" || page.Name != "Page" {
+		t.Fatalf("unexpected page: %+v", page)
+	}
+	t.Setenv("GOFLAGS", "-mod=mod")
+	p, loadErr = forg.Load(dir)
+	if loadErr == nil {
+		if _, inspectErr = p.Inspect(context.TODO()); inspectErr == nil {
+			t.Fatal("expect failure when vendor is disabled and module cache is empty")
+		}
+	}
+}
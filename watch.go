@@ -0,0 +1,199 @@
+package forg
+
+import (
+	"context"
+	"github.com/aacfactory/errors"
+	"github.com/aacfactory/forg/files"
+	"github.com/aacfactory/forg/module"
+	"github.com/aacfactory/forg/processes"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	defaultWatchInterval = 500 * time.Millisecond
+)
+
+// WatchHandler is called for each round of regeneration, changed are the services which are regenerated,
+// results must be drained before returning.
+type WatchHandler func(changed []string, results <-chan processes.Result)
+
+// Watch generates all code files, then polls the modules dir and regenerates code files of changed services,
+// the deploys file is regenerated only when the set of services is changed.
+// It blocks until ctx is done.
+func (project *Project) Watch(ctx context.Context, interval time.Duration, handler WatchHandler) (err error) {
+	if handler == nil {
+		err = errors.Warning("forg: project watch failed").WithCause(errors.Warning("handler is nil"))
+		return
+	}
+	if interval <= 0 {
+		interval = defaultWatchInterval
+	}
+	parseErr := project.Mod.Parse(ctx)
+	if parseErr != nil {
+		err = errors.Warning("forg: project watch failed").WithCause(parseErr)
+		return
+	}
+	snapshot, snapshotErr := project.snapshot()
+	if snapshotErr != nil {
+		err = errors.Warning("forg: project watch failed").WithCause(snapshotErr)
+		return
+	}
+	services, servicesErr := project.Mod.Services()
+	if servicesErr != nil {
+		err = errors.Warning("forg: project watch failed").WithCause(servicesErr)
+		return
+	}
+	names := serviceNames(services)
+	handler(names, project.coding(services, services, true).Start(ctx))
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			current, currentErr := project.snapshot()
+			if currentErr != nil {
+				handler(nil, failedResults(errors.Warning("forg: project watch failed").WithCause(currentErr)))
+				continue
+			}
+			paths := snapshot.changes(current, project.Mod.Path, project.Config.Modules)
+			snapshot = current
+			if len(paths) == 0 {
+				continue
+			}
+			project.Mod.Invalidate(paths...)
+			services, servicesErr = project.Mod.Services()
+			if servicesErr != nil {
+				handler(nil, failedResults(errors.Warning("forg: project watch failed").WithCause(servicesErr)))
+				continue
+			}
+			affected := make(module.Services, 0, len(paths))
+			changed := make([]string, 0, len(paths))
+			for _, service := range services {
+				for _, path := range paths {
+					if service.Path == path {
+						affected = append(affected, service)
+						changed = append(changed, service.Name)
+						break
+					}
+				}
+			}
+			currentNames := serviceNames(services)
+			deploys := strings.Join(currentNames, ",") != strings.Join(names, ",")
+			names = currentNames
+			if len(affected) == 0 && !deploys {
+				continue
+			}
+			handler(changed, project.coding(services, affected, deploys).Start(ctx))
+		}
+	}
+}
+
+func serviceNames(services module.Services) (names []string) {
+	names = make([]string, 0, len(services))
+	for _, service := range services {
+		names = append(names, service.Name)
+	}
+	return
+}
+
+func failedResults(err error) (results <-chan processes.Result) {
+	ch := make(chan processes.Result, 1)
+	ch <- processes.Result{
+		Error: err,
+	}
+	close(ch)
+	results = ch
+	return
+}
+
+type watchedFile struct {
+	modTime time.Time
+	size    int64
+}
+
+// watchSnapshot is keyed by service dir name, and then by filename in the service dir.
+type watchSnapshot map[string]map[string]watchedFile
+
+// changes returns package paths of services which files are added, removed or modified.
+func (snapshot watchSnapshot) changes(current watchSnapshot, modPath string, modules string) (paths []string) {
+	dirs := make([]string, 0, 1)
+	for dir, files := range current {
+		prev, has := snapshot[dir]
+		if !has || len(prev) != len(files) {
+			dirs = append(dirs, dir)
+			continue
+		}
+		for filename, file := range files {
+			prevFile, exist := prev[filename]
+			if !exist || !prevFile.modTime.Equal(file.modTime) || prevFile.size != file.size {
+				dirs = append(dirs, dir)
+				break
+			}
+		}
+	}
+	for dir := range snapshot {
+		if _, has := current[dir]; !has {
+			dirs = append(dirs, dir)
+		}
+	}
+	sort.Strings(dirs)
+	paths = make([]string, 0, len(dirs))
+	for _, dir := range dirs {
+		paths = append(paths, modPath+"/"+modules+"/"+dir)
+	}
+	return
+}
+
+// snapshot walks the modules dir in the file system of project, so it sees the same files as parsing.
+func (project *Project) snapshot() (snapshot watchSnapshot, err error) {
+	root := files.Name(filepath.Join(project.Mod.Dir, project.Config.Modules))
+	snapshot = make(watchSnapshot)
+	err = fs.WalkDir(project.fsys, root, func(name string, entry fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			if os.IsNotExist(walkErr) && name != root {
+				return nil
+			}
+			return walkErr
+		}
+		if entry.IsDir() || path.Ext(name) != ".go" || entry.Name() == project.Config.Output {
+			return nil
+		}
+		rel := strings.TrimPrefix(name, root+"/")
+		idx := strings.IndexByte(rel, '/')
+		if idx < 0 {
+			return nil
+		}
+		info, infoErr := entry.Info()
+		if infoErr != nil {
+			if os.IsNotExist(infoErr) {
+				return nil
+			}
+			return infoErr
+		}
+		dir := rel[0:idx]
+		watched, has := snapshot[dir]
+		if !has {
+			watched = make(map[string]watchedFile)
+			snapshot[dir] = watched
+		}
+		watched[rel[idx+1:]] = watchedFile{
+			modTime: info.ModTime(),
+			size:    info.Size(),
+		}
+		return nil
+	})
+	if err != nil {
+		err = errors.Warning("forg: snapshot modules dir failed").WithCause(err).WithMeta("dir", root)
+		return
+	}
+	return
+}
@@ -0,0 +1,79 @@
+package forg_test
+
+import (
+	"context"
+	"github.com/aacfactory/forg"
+	"github.com/aacfactory/forg/processes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProject_Watch(t *testing.T) {
+	dir := copyProject(t, filepath.Join("testdata", "standalone"))
+	p, loadErr := forg.Load(dir)
+	if loadErr != nil {
+		t.Fatalf("%+v", loadErr)
+	}
+	ctx, cancel := context.WithTimeout(context.TODO(), 10*time.Second)
+	defer cancel()
+	rounds := make(chan []string, 8)
+	done := make(chan error, 1)
+	go func() {
+		done <- p.Watch(ctx, 20*time.Millisecond, func(changed []string, results <-chan processes.Result) {
+			for result := range results {
+				if result.Error != nil {
+					t.Errorf("%+v", result.Error)
+				}
+			}
+			rounds <- changed
+		})
+	}()
+	next := func() []string {
+		select {
+		case changed := <-rounds:
+			return changed
+		case <-ctx.Done():
+			t.Fatal("watch round timeout")
+			return nil
+		}
+	}
+	if changed := next(); strings.Join(changed, ",") != "users" {
+		t.Fatalf("unexpected first round: %v", changed)
+	}
+	deploys, _ := os.ReadFile(filepath.Join(dir, "modules", "fns.go"))
+
+	find := "package users\n\nimport (\n\t\"context\"\n\t\"github.com/aacfactory/errors\"\n)\n\n// find\n// @fn find\nfunc find(ctx context.Context, param GetParam) (result User, err errors.CodeError) {\n\treturn\n}\n"
+	if err := os.WriteFile(filepath.Join(dir, "modules", "users", "find.go"), []byte(find), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if changed := next(); strings.Join(changed, ",") != "users" {
+		t.Fatalf("unexpected round: %v", changed)
+	}
+	fns, _ := os.ReadFile(filepath.Join(dir, "modules", "users", "fns.go"))
+	if !strings.Contains(string(fns), "_findFn") {
+		t.Fatalf("users fns.go was not regenerated:\n%s", fns)
+	}
+	if current, _ := os.ReadFile(filepath.Join(dir, "modules", "fns.go")); string(current) != string(deploys) {
+		t.Fatal("deploys file must not be rewritten when services are not changed")
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, "modules", "posts"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "modules", "posts", "doc.go"), []byte("// Package posts\n// @service posts\npackage posts\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if changed := next(); strings.Join(changed, ",") != "posts" {
+		t.Fatalf("unexpected round: %v", changed)
+	}
+	if current, _ := os.ReadFile(filepath.Join(dir, "modules", "fns.go")); !strings.Contains(string(current), "posts.Service()") {
+		t.Fatalf("deploys file was not regenerated:\n%s", current)
+	}
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("%+v", err)
+	}
+}
@@ -0,0 +1,47 @@
+package forg_test
+
+import (
+	"context"
+	"github.com/aacfactory/forg"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoad_Workspace(t *testing.T) {
+	dir := copyProject(t, filepath.Join("testdata", "standalone"))
+	work := filepath.Join(dir, "go.work")
+	if err := os.WriteFile(work, []byte("go 1.20\n\nuse .\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GOWORK", "")
+	p, loadErr := forg.Load(dir)
+	if loadErr != nil {
+		t.Fatalf("%+v", loadErr)
+	}
+	if p.Workspace() != filepath.ToSlash(work) {
+		t.Fatalf("expect workspace %s, got %q", work, p.Workspace())
+	}
+	model, inspectErr := p.Inspect(context.TODO())
+	if inspectErr != nil {
+		t.Fatalf("%+v", inspectErr)
+	}
+	if model.Workspace != p.Workspace() || len(model.Services) != 1 {
+		t.Errorf("unexpected model: %+v", model)
+	}
+	p, loadErr = forg.Load(dir, forg.WithWorkspace("off"))
+	if loadErr != nil {
+		t.Fatalf("%+v", loadErr)
+	}
+	if p.Workspace() != "" {
+		t.Errorf("expect no workspace, got %s", p.Workspace())
+	}
+	t.Setenv("GOWORK", "off")
+	p, loadErr = forg.Load(dir)
+	if loadErr != nil {
+		t.Fatalf("%+v", loadErr)
+	}
+	if p.Workspace() != "" {
+		t.Errorf("expect no workspace when GOWORK is off, got %s", p.Workspace())
+	}
+}